@@ -17,6 +17,16 @@ import (
 // @BasePath /api
 // @Host localhost:8080
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT issued to the caller, as "Bearer <token>". The roles claim grants reader, editor or admin access.
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Key of a service client issued by an admin, its scopes grant the roles.
+
 func main() {
 	// configuration
 	cfg, err := config.NewConfig()
@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
-	HTTP      HTTPConfig
-	PG        PGConfig
-	Redis     RedisConfig
-	Logger    LoggerConfig
-	PersonApi PersonApiConfig
-	Kafka     KafkaConfig
+	HTTP        HTTPConfig
+	PG          PGConfig
+	Redis       RedisConfig
+	Logger      LoggerConfig
+	PersonApi   PersonApiConfig
+	Kafka       KafkaConfig
+	Purge       PurgeConfig
+	Idempotency IdempotencyConfig
+	Auth        AuthConfig
+	Health      HealthConfig
+	Tracing     TracingConfig
 }
 
 type (
@@ -20,6 +26,27 @@ type (
 		Host    string `env:"HTTP_HOST" yaml:"host"`
 		Port    string `env:"HTTP_PORT" yaml:"port"`
 		Address string
+		// RequestTimeout is the overall deadline of an API or GraphQL request. Export and import stream
+		// whole tables and files, so they get the longer ExportTimeout instead.
+		RequestTimeout time.Duration `env:"HTTP_REQUEST_TIMEOUT" envDefault:"15s" yaml:"requestTimeout"`
+		ExportTimeout  time.Duration `env:"HTTP_EXPORT_TIMEOUT"  envDefault:"10m" yaml:"exportTimeout"`
+		RateLimit      RateLimitConfig
+	}
+
+	// RateLimitConfig sets the token buckets of each client per route group: the regular API, the export and
+	// import transfers and GraphQL, where every root field of an operation takes a token. A zero rate turns
+	// the limit of the group off. The buckets live in memory, or in Redis to be shared by several replicas.
+	// The IP bucket is checked before authentication, so that it also holds back the requests that fail it.
+	RateLimitConfig struct {
+		Backend           string `env:"RATE_LIMIT_BACKEND"             envDefault:"memory" yaml:"backend"`
+		IPPerMinute       int    `env:"RATE_LIMIT_IP_PER_MINUTE"       envDefault:"1200"   yaml:"ipPerMinute"`
+		IPBurst           int    `env:"RATE_LIMIT_IP_BURST"            envDefault:"120"    yaml:"ipBurst"`
+		APIPerMinute      int    `env:"RATE_LIMIT_API_PER_MINUTE"      envDefault:"600"    yaml:"apiPerMinute"`
+		APIBurst          int    `env:"RATE_LIMIT_API_BURST"           envDefault:"60"     yaml:"apiBurst"`
+		TransferPerMinute int    `env:"RATE_LIMIT_TRANSFER_PER_MINUTE" envDefault:"6"      yaml:"transferPerMinute"`
+		TransferBurst     int    `env:"RATE_LIMIT_TRANSFER_BURST"      envDefault:"2"      yaml:"transferBurst"`
+		GraphQLPerMinute  int    `env:"RATE_LIMIT_GRAPHQL_PER_MINUTE"  envDefault:"600"    yaml:"graphqlPerMinute"`
+		GraphQLBurst      int    `env:"RATE_LIMIT_GRAPHQL_BURST"       envDefault:"60"     yaml:"graphqlBurst"`
 	}
 
 	PGConfig struct {
@@ -30,6 +57,8 @@ type (
 		Port     uint16 `env:"POSTGRES_PORT"     yaml:"port"`
 		DB       string `env:"POSTGRES_DB"       yaml:"DB"`
 		SSLMode  string `env:"POSTGRES_SSL_MODE" yaml:"SSLMode"`
+		// QueryTimeout limits a single query, exports are bounded by the request deadline instead.
+		QueryTimeout time.Duration `env:"POSTGRES_QUERY_TIMEOUT" envDefault:"5s" yaml:"queryTimeout"`
 	}
 
 	RedisConfig struct {
@@ -37,6 +66,8 @@ type (
 		Port     string `env:"REDIS_PORT"     yaml:"port"`
 		Password string `env:"REDIS_PASSWORD" yaml:"password"`
 		DB       int    `env:"REDIS_DB"       yaml:"DB"`
+		// Timeout limits a single Redis command.
+		Timeout time.Duration `env:"REDIS_TIMEOUT" envDefault:"500ms" yaml:"timeout"`
 	}
 
 	LoggerConfig struct {
@@ -48,6 +79,8 @@ type (
 		AgeURL         string `env:"AGE_API_URL"    yaml:"ageURL"`
 		GenderURL      string `env:"GENDER_API_URL" yaml:"genderURL"`
 		NationalityURL string `env:"NATION_API_URL" yaml:"nationalityURL"`
+		// Timeout limits a single enrichment API call.
+		Timeout time.Duration `env:"PERSON_API_TIMEOUT" envDefault:"5s" yaml:"timeout"`
 	}
 
 	KafkaConfig struct {
@@ -55,6 +88,53 @@ type (
 		Broker         string `env:"KAFKA_BROKER"             yaml:"broker"`
 		FioTopic       string `env:"KAFKA_FIO_TOPIC"          yaml:"fioTopic"`
 		FioFailedTopic string `env:"KAFKA_FIO_FAILED_TOPIC"   yaml:"fioFailedTopic"`
+		// MessageTimeout is the overall deadline of handling a single FIO message.
+		MessageTimeout time.Duration `env:"KAFKA_MESSAGE_TIMEOUT" envDefault:"30s" yaml:"messageTimeout"`
+	}
+
+	// PurgeConfig controls how long deleted people stay in the trash. A zero retention disables the purge.
+	PurgeConfig struct {
+		Retention time.Duration `env:"PURGE_RETENTION" envDefault:"720h" yaml:"retention"`
+		Interval  time.Duration `env:"PURGE_INTERVAL"  envDefault:"1h"   yaml:"interval"`
+	}
+
+	// IdempotencyConfig controls how long the response to an Idempotency-Key is remembered.
+	IdempotencyConfig struct {
+		Expiration time.Duration `env:"IDEMPOTENCY_EXPIRATION" envDefault:"24h" yaml:"expiration"`
+	}
+
+	// AuthConfig holds the keys bearer tokens are verified with: an HMAC secret, a PEM encoded RSA public key
+	// or a JWKS file, at least one of them is required. Issuer and audience are checked when set.
+	AuthConfig struct {
+		JWTSecret        string `env:"AUTH_JWT_SECRET"          yaml:"jwtSecret"`
+		JWTPublicKeyFile string `env:"AUTH_JWT_PUBLIC_KEY_FILE" yaml:"jwtPublicKeyFile"`
+		JWKSFile         string `env:"AUTH_JWKS_FILE"           yaml:"jwksFile"`
+		Issuer           string `env:"AUTH_JWT_ISSUER"          yaml:"issuer"`
+		Audience         string `env:"AUTH_JWT_AUDIENCE"        yaml:"audience"`
+		// APIKeyCacheExpiration is how long an api key lookup is cached. Revoking a key replaces the cached
+		// lookup with the revoked key, so it takes effect right away.
+		APIKeyCacheExpiration time.Duration `env:"AUTH_API_KEY_CACHE_EXPIRATION" envDefault:"30s" yaml:"apiKeyCacheExpiration"`
+		// APIKeyMissExpiration is how long an unknown api key is remembered as such, so that guessed keys
+		// do not reach the database.
+		APIKeyMissExpiration time.Duration `env:"AUTH_API_KEY_MISS_EXPIRATION" envDefault:"10s" yaml:"apiKeyMissExpiration"`
+	}
+
+	// HealthConfig controls the readiness checks. On shutdown the service reports not ready for ShutdownDelay
+	// before it stops accepting requests, so that the orchestrator stops routing traffic to it first.
+	HealthConfig struct {
+		CheckTimeout  time.Duration `env:"HEALTH_CHECK_TIMEOUT"  envDefault:"2s" yaml:"checkTimeout"`
+		ShutdownDelay time.Duration `env:"HEALTH_SHUTDOWN_DELAY" envDefault:"5s" yaml:"shutdownDelay"`
+	}
+
+	// TracingConfig selects where the spans go: "otlp" sends them to an OpenTelemetry collector over gRPC,
+	// "stdout" prints them for local runs and "none" turns tracing off. SampleRatio is the share of new
+	// traces that are recorded, the traces started by the callers follow their decision.
+	TracingConfig struct {
+		Exporter     string  `env:"TRACING_EXPORTER"      envDefault:"none"           yaml:"exporter"`
+		OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317" yaml:"otlpEndpoint"`
+		OTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" envDefault:"true"           yaml:"otlpInsecure"`
+		SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO"  envDefault:"1"              yaml:"sampleRatio"`
+		ServiceName  string  `env:"TRACING_SERVICE_NAME"  envDefault:"fio-service"    yaml:"serviceName"`
 	}
 )
 
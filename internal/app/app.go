@@ -4,20 +4,31 @@ import (
 	"context"
 	"fmt"
 	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
 	"github.com/khasmag06/effective-mobile-test/internal/controller/api"
+	apiKeyCache "github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/cache"
+	apiKeyRepo "github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/postgres"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/idempotency"
 	"github.com/khasmag06/effective-mobile-test/internal/repo/people/cache"
 	peopleRepo "github.com/khasmag06/effective-mobile-test/internal/repo/people/postgres"
+	"github.com/khasmag06/effective-mobile-test/internal/service/apikeys"
 	"github.com/khasmag06/effective-mobile-test/internal/service/people"
 	"github.com/khasmag06/effective-mobile-test/internal/webapi"
+	"github.com/khasmag06/effective-mobile-test/pkg/health"
 	"github.com/khasmag06/effective-mobile-test/pkg/httpserver"
 	"github.com/khasmag06/effective-mobile-test/pkg/kafka"
 	"github.com/khasmag06/effective-mobile-test/pkg/logger"
+	"github.com/khasmag06/effective-mobile-test/pkg/metrics"
 	"github.com/khasmag06/effective-mobile-test/pkg/postgres"
+	"github.com/khasmag06/effective-mobile-test/pkg/ratelimit"
 	"github.com/khasmag06/effective-mobile-test/pkg/redis"
+	"github.com/khasmag06/effective-mobile-test/pkg/tracing"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func Run(cfg *config.Config) {
@@ -27,7 +38,20 @@ func Run(cfg *config.Config) {
 	}
 	defer func() { _ = l.Sync() }()
 
-	ctx := context.Background()
+	// ctx is cancelled on shutdown, stopping the background work and the requests that outlive the drain.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.New(ctx, cfg.Tracing)
+	if err != nil {
+		l.Fatalf("failed to set up tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			l.Errorf("app - Run - shutdownTracing: %v", err)
+		}
+	}()
+
 	db, err := postgres.NewDB(ctx, cfg.PG)
 	if err != nil {
 		l.Fatalf("failed to connect to postgres db: %s", err)
@@ -39,11 +63,16 @@ func Run(cfg *config.Config) {
 		l.Fatalf("failed to connect to postgres redis db: %s", err)
 	}
 
-	repo := peopleRepo.New(db.Pool)
-	peopleCache := cache.New(redisDB, repo, l)
+	m := metrics.New()
+	if err := m.Register(metrics.NewPoolCollector(db.Pool)); err != nil {
+		l.Fatalf("failed to register database pool metrics: %v", err)
+	}
+
+	repo := peopleRepo.New(db.Pool, cfg.PG.QueryTimeout)
+	peopleCache := cache.New(redisDB, repo, m, l)
 	service := people.New(peopleCache)
 
-	fioInfoApi := webapi.New(cfg.PersonApi, service, l)
+	fioInfoApi := webapi.New(cfg.PersonApi, service, m, l)
 
 	kafkaClient, err := kafka.NewKafkaClient(cfg.Kafka.BrokerURLs)
 	if err != nil {
@@ -57,24 +86,87 @@ func Run(cfg *config.Config) {
 		for {
 			select {
 			case msg := <-messages:
-				l.Infof("Received message from topic %s: %s", consumeTopic, string(msg.Value))
+				// Work done on behalf of the message is traced and logged with its position in the topic.
+				msgCtx, span := kafka.StartConsumerSpan(ctx, msg)
+				msgCtx = logger.WithFields(msgCtx, "topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+				l.InfofContext(msgCtx, "Received message: %s", string(msg.Value))
+				m.KafkaMessageConsumed(msg.Topic)
 
-				if err := fioInfoApi.AddFioData(msg.Value); err != nil {
-					l.Error(err.Error())
+				timeoutCtx, cancelMsg := context.WithTimeout(msgCtx, cfg.Kafka.MessageTimeout)
+				err := fioInfoApi.AddFioData(timeoutCtx, msg.Value)
+				cancelMsg()
+				if err != nil {
+					l.ErrorContext(msgCtx, err.Error())
+					m.KafkaMessageFailed(msg.Topic)
 					errorMessage := fmt.Sprintf("%s: %s", err.Error(), string(msg.Value))
-					kafkaClient.SendMessageToTopic(cfg.Kafka.FioFailedTopic, []byte(errorMessage))
-					continue
+					if err := kafkaClient.SendMessageToTopic(msgCtx, cfg.Kafka.FioFailedTopic, []byte(errorMessage)); err != nil {
+						l.ErrorfContext(msgCtx, "failed to send message to topic %s: %v", cfg.Kafka.FioFailedTopic, err)
+					} else {
+						m.KafkaMessageSent(cfg.Kafka.FioFailedTopic)
+					}
 				}
+				tracing.End(span, err)
 			case err := <-errors:
 				l.Errorf("failed to read message from topic %s: %v", consumeTopic, err)
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
 
+	// Trash purge
+	if cfg.Purge.Retention > 0 && cfg.Purge.Interval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Purge.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				purged, err := service.PurgeDeletedPeople(ctx, cfg.Purge.Retention)
+				if err != nil {
+					l.Errorf("failed to purge deleted people: %v", err)
+					continue
+				}
+				if purged > 0 {
+					l.Infof("purged %d deleted people older than %s", purged, cfg.Purge.Retention)
+				}
+			}
+		}()
+	}
+
 	// HTTP Server
 	l.Info("Starting api server...")
-	handler := api.NewHandler(service, l)
-	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
+	idempotencyStore := idempotency.New(redisDB, cfg.Idempotency.Expiration)
+	tokenVerifier, err := auth.NewJWTVerifier(cfg.Auth)
+	if err != nil {
+		l.Fatalf("failed to set up token verification: %v", err)
+	}
+	keyRepo := apiKeyRepo.New(db.Pool, cfg.PG.QueryTimeout)
+	keyCache := apiKeyCache.New(redisDB, keyRepo, cfg.Auth.APIKeyCacheExpiration, cfg.Auth.APIKeyMissExpiration, m, l)
+	keyService := apikeys.New(keyCache, l)
+	rateLimiter, err := ratelimit.New(cfg.HTTP.RateLimit.Backend, redisDB)
+	if err != nil {
+		l.Fatalf("failed to set up rate limiting: %v", err)
+	}
+	// The enrichment APIs only fill in missing data, the service keeps working without them.
+	enrichmentClient := &http.Client{}
+	healthChecker := health.New(cfg.Health.CheckTimeout,
+		health.Check{Name: "postgres", Critical: true, Check: db.Ping},
+		health.Check{Name: "redis", Critical: true, Check: func(ctx context.Context) error { return redisDB.Ping(ctx).Err() }},
+		health.Check{Name: "kafka", Critical: true, Check: kafkaClient.Ping},
+		health.Check{Name: "age_api", Check: health.HTTPReachable(enrichmentClient, cfg.PersonApi.AgeURL)},
+		health.Check{Name: "gender_api", Check: health.HTTPReachable(enrichmentClient, cfg.PersonApi.GenderURL)},
+		health.Check{Name: "nationality_api", Check: health.HTTPReachable(enrichmentClient, cfg.PersonApi.NationalityURL)},
+	)
+	handler := api.NewHandler(cfg.HTTP, service, keyService, fioInfoApi, idempotencyStore, tokenVerifier, rateLimiter, healthChecker, m, l)
+	httpServer := httpserver.New(handler,
+		httpserver.Port(cfg.HTTP.Port),
+		httpserver.WriteTimeout(cfg.HTTP.ExportTimeout),
+		httpserver.BaseContext(ctx),
+	)
 
 	// Waiting signal
 	interrupt := make(chan os.Signal, 1)
@@ -84,12 +176,15 @@ func Run(cfg *config.Config) {
 	case s := <-interrupt:
 		l.Info("app - Run - signal: " + s.String())
 	case err = <-httpServer.Notify():
-		l.Errorf("app - Run - httpServer.Notify: %w", err)
+		l.Errorf("app - Run - httpServer.Notify: %v", err)
 	}
 
-	// Shutdown
+	// Shutdown, the readiness probe fails for a while before the server stops accepting requests
+	healthChecker.Shutdown()
+	time.Sleep(cfg.Health.ShutdownDelay)
 	err = httpServer.Shutdown()
 	if err != nil {
-		l.Errorf("app - Run - httpServer.Shutdown: %w", err)
+		l.Errorf("app - Run - httpServer.Shutdown: %v", err)
 	}
+	cancel()
 }
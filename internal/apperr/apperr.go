@@ -0,0 +1,77 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"net"
+)
+
+// Kind is the class of an error that decides how it is reported to the client.
+type Kind string
+
+const (
+	KindValidation      Kind = "validation"
+	KindUnauthenticated Kind = "unauthenticated"
+	KindForbidden       Kind = "forbidden"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindRateLimited     Kind = "rate_limited"
+	KindUnavailable     Kind = "unavailable"
+	KindInternal        Kind = "internal"
+)
+
+// Error is a domain error of a known kind with a stable code clients can rely on.
+type Error struct {
+	Kind Kind
+	Code string
+	msg  string
+}
+
+func New(kind Kind, code, msg string) *Error {
+	return &Error{Kind: kind, Code: code, msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// KindOf classifies err. Validation failures, domain errors and the failures of an unreachable
+// or timed out dependency are recognised anywhere in the chain, anything else is internal.
+func KindOf(err error) Kind {
+	var (
+		appErr        *Error
+		validationErr *validator.ValidationError
+		netErr        net.Error
+	)
+	switch {
+	case errors.As(err, &appErr):
+		return appErr.Kind
+	case errors.As(err, &validationErr):
+		return KindValidation
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled),
+		pgconn.Timeout(err), errors.As(err, &netErr):
+		return KindUnavailable
+	default:
+		return KindInternal
+	}
+}
+
+// CodeOf returns the code of a domain error in the chain of err, or its kind otherwise.
+func CodeOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return string(KindOf(err))
+}
+
+// FieldsOf returns the failing fields of a validation error in the chain of err.
+func FieldsOf(err error) []validator.FieldError {
+	var validationErr *validator.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+)
+
+// Role grants access to a group of operations. Each role includes the permissions of the lower ones:
+// readers can only read, editors can also change people and admins can manage the trash.
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleReader: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+var (
+	ErrUnauthenticated = apperr.New(apperr.KindUnauthenticated, "unauthenticated", "missing or invalid credentials")
+	ErrForbidden       = apperr.New(apperr.KindForbidden, "forbidden", "the caller is not allowed to perform this operation")
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Subject string
+	Roles   []Role
+}
+
+// HasRole reports whether any of the roles of the identity includes the required one.
+func (i Identity) HasRole(required Role) bool {
+	for _, role := range i.Roles {
+		if roleRanks[role] >= roleRanks[required] {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx that carries the authenticated caller.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller stored in ctx, if the request was authenticated.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
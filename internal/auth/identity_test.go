@@ -0,0 +1,31 @@
+package auth_test
+
+import (
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIdentity_HasRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []auth.Role
+		required auth.Role
+		expected bool
+	}{
+		{name: "same role", roles: []auth.Role{auth.RoleEditor}, required: auth.RoleEditor, expected: true},
+		{name: "higher role", roles: []auth.Role{auth.RoleAdmin}, required: auth.RoleReader, expected: true},
+		{name: "lower role", roles: []auth.Role{auth.RoleReader}, required: auth.RoleEditor, expected: false},
+		{name: "one of the roles is enough", roles: []auth.Role{auth.RoleReader, auth.RoleAdmin}, required: auth.RoleAdmin, expected: true},
+		{name: "unknown role", roles: []auth.Role{"owner"}, required: auth.RoleReader, expected: false},
+		{name: "no roles", required: auth.RoleReader, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity := auth.Identity{Subject: "user-1", Roles: test.roles}
+
+			assert.Equal(t, test.expected, identity.HasRole(test.required), "Test case %s failed: Result not as expected", test.name)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret   = "test-secret"
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "fio-service"
+)
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	hmacVerifier, err := auth.NewJWTVerifier(config.AuthConfig{JWTSecret: testSecret, Issuer: testIssuer, Audience: testAudience})
+	require.NoError(t, err)
+	pemVerifier, err := auth.NewJWTVerifier(config.AuthConfig{JWTPublicKeyFile: writePublicKeyPEM(t, dir, &rsaKey.PublicKey)})
+	require.NoError(t, err)
+	jwksVerifier, err := auth.NewJWTVerifier(config.AuthConfig{JWKSFile: writeJWKS(t, dir, "key-1", &rsaKey.PublicKey)})
+	require.NoError(t, err)
+
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":   "user-1",
+			"iss":   testIssuer,
+			"aud":   testAudience,
+			"exp":   now.Add(time.Hour).Unix(),
+			"roles": []string{"editor"},
+		}
+	}
+	withClaim := func(name string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	publicKeyPEM, err := os.ReadFile(filepath.Join(dir, "public.pem"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		verifier         *auth.JWTVerifier
+		token            string
+		expectedIdentity auth.Identity
+		expectedErr      bool
+	}{
+		{
+			name:             "valid hmac token",
+			verifier:         hmacVerifier,
+			token:            signHMAC(t, jwt.SigningMethodHS256, validClaims(), testSecret),
+			expectedIdentity: auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}},
+		},
+		{
+			name:             "expired within the clock skew",
+			verifier:         hmacVerifier,
+			token:            signHMAC(t, jwt.SigningMethodHS256, withClaim("exp", now.Add(-10*time.Second).Unix()), testSecret),
+			expectedIdentity: auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}},
+		},
+		{
+			name:        "expired token",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, withClaim("exp", now.Add(-time.Minute).Unix()), testSecret),
+			expectedErr: true,
+		},
+		{
+			name:        "not yet valid token",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, withClaim("nbf", now.Add(time.Minute).Unix()), testSecret),
+			expectedErr: true,
+		},
+		{
+			name:        "token without expiration",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, withClaim("exp", nil), testSecret),
+			expectedErr: true,
+		},
+		{
+			name:        "wrong secret",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, validClaims(), "other-secret"),
+			expectedErr: true,
+		},
+		{
+			name:        "wrong issuer",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, withClaim("iss", "https://other.example.com"), testSecret),
+			expectedErr: true,
+		},
+		{
+			name:        "wrong audience",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, withClaim("aud", "other-service"), testSecret),
+			expectedErr: true,
+		},
+		{
+			name:        "token without subject",
+			verifier:    hmacVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, withClaim("sub", nil), testSecret),
+			expectedErr: true,
+		},
+		{
+			name:             "token without roles",
+			verifier:         hmacVerifier,
+			token:            signHMAC(t, jwt.SigningMethodHS256, withClaim("roles", nil), testSecret),
+			expectedIdentity: auth.Identity{Subject: "user-1"},
+		},
+		{
+			name:        "rsa token when only hmac is configured",
+			verifier:    hmacVerifier,
+			token:       signRSA(t, validClaims(), rsaKey, ""),
+			expectedErr: true,
+		},
+		{
+			name:        "unsigned token",
+			verifier:    hmacVerifier,
+			token:       signNone(t, validClaims()),
+			expectedErr: true,
+		},
+		{
+			name:             "valid pem token",
+			verifier:         pemVerifier,
+			token:            signRSA(t, validClaims(), rsaKey, ""),
+			expectedIdentity: auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}},
+		},
+		{
+			name:        "hmac token signed with the public key when only rsa is configured",
+			verifier:    pemVerifier,
+			token:       signHMAC(t, jwt.SigningMethodHS256, validClaims(), string(publicKeyPEM)),
+			expectedErr: true,
+		},
+		{
+			name:             "valid jwks token",
+			verifier:         jwksVerifier,
+			token:            signRSA(t, validClaims(), rsaKey, "key-1"),
+			expectedIdentity: auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}},
+		},
+		{
+			name:        "unknown key id",
+			verifier:    jwksVerifier,
+			token:       signRSA(t, validClaims(), rsaKey, "key-2"),
+			expectedErr: true,
+		},
+		{
+			name:        "missing key id",
+			verifier:    jwksVerifier,
+			token:       signRSA(t, validClaims(), rsaKey, ""),
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, err := test.verifier.Verify(test.token)
+
+			if test.expectedErr {
+				assert.ErrorIs(t, err, auth.ErrUnauthenticated, "Test case %s failed: Error not as expected", test.name)
+			} else {
+				assert.NoError(t, err, "Test case %s failed: Error not as expected", test.name)
+			}
+			assert.Equal(t, test.expectedIdentity, identity, "Test case %s failed: Identity not as expected", test.name)
+		})
+	}
+}
+
+func TestNewJWTVerifier(t *testing.T) {
+	dir := t.TempDir()
+	invalidPEM := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPEM, []byte("not a key"), 0o600))
+
+	tests := []struct {
+		name        string
+		cfg         config.AuthConfig
+		expectedErr bool
+	}{
+		{name: "hmac secret", cfg: config.AuthConfig{JWTSecret: testSecret}},
+		{name: "no keys", cfg: config.AuthConfig{}, expectedErr: true},
+		{name: "missing public key file", cfg: config.AuthConfig{JWTPublicKeyFile: filepath.Join(dir, "missing.pem")}, expectedErr: true},
+		{name: "invalid public key", cfg: config.AuthConfig{JWTPublicKeyFile: invalidPEM}, expectedErr: true},
+		{name: "invalid jwks", cfg: config.AuthConfig{JWKSFile: invalidPEM}, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := auth.NewJWTVerifier(test.cfg)
+
+			assert.Equal(t, test.expectedErr, err != nil, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func signHMAC(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func signRSA(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func signNone(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	return token
+}
+
+func writePublicKeyPEM(t *testing.T, dir string, key *rsa.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func writeJWKS(t *testing.T, dir, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+	keySet := map[string]any{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec-key", "crv": "P-256"},
+		{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		},
+	}}
+	data, err := json.Marshal(keySet)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
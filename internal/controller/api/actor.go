@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+)
+
+// withActor stores the caller of the request in its context, so that the changes it makes are
+// recorded in the person history along with the given source. Authenticated requests are
+// attributed to the subject of their identity, the others to the client IP. The caller cannot
+// name itself, the history only ever shows who was verified to make a change.
+func withActor(source string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.ClientIP()
+		if identity, ok := auth.IdentityFromContext(c.Request.Context()); ok {
+			name = identity.Subject
+		}
+		ctx := entity.WithActor(c.Request.Context(), entity.Actor{Name: name, Source: source})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package api_test
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_Actor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, m := newTestHandler(ctrl, config.HTTPConfig{})
+	m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+	var actor entity.Actor
+	m.peopleService.EXPECT().DeletePersonData(gomock.Any(), 7, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _, _ int) error {
+			actor = entity.ActorFromContext(ctx)
+			return nil
+		})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v2/people/7", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	// The caller cannot pass itself off as someone else in the history
+	req.Header.Set("X-Actor", "admin")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, entity.Actor{Name: "user-1", Source: entity.SourceREST}, actor)
+}
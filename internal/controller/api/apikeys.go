@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/repoerrs"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"net/http"
+)
+
+// @Tags API keys
+// @Summary issueAPIKey
+// @Description issue a long-lived key for a service client, sent in the X-API-Key header.
+// @Description The key is returned only once, it cannot be retrieved later.
+// @ID issueAPIKey
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param input body entity.APIKeyRequest true "key name, scopes (reader, editor, admin) and optional expiry"
+// @Success 201 {object} entity.IssuedAPIKey
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /apikeys [post]
+func (h *Handler) issueAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	var keyReq entity.APIKeyRequest
+	if err := c.ShouldBindJSON(&keyReq); err != nil {
+		h.logger.ErrorfContext(ctx, "json body binding error: %v", err)
+		writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
+		return
+	}
+	issued, err := h.apiKeyService.IssueAPIKey(ctx, keyReq)
+	if err != nil {
+		var validationErr *validator.ValidationError
+		if errors.As(err, &validationErr) {
+			h.logger.ErrorfContext(ctx, "validation err: %v", err)
+			writeProblem(c, http.StatusBadRequest, err)
+			return
+		}
+		h.logger.ErrorfContext(ctx, "failed to issue api key: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, issued)
+}
+
+// @Tags API keys
+// @Summary getAPIKeys
+// @Description list all api keys including the revoked and expired ones, newest first
+// @ID getAPIKeys
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} entity.APIKey
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /apikeys [get]
+func (h *Handler) getAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	keys, err := h.apiKeyService.GetAPIKeys(ctx)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to fetch api keys: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Tags API keys
+// @Summary revokeAPIKey
+// @Description revoke an api key, it stops working right away
+// @ID revokeAPIKey
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int64 true "ID of the key to revoke"
+// @Success 200 {object} successResponse
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /apikeys/{id} [delete]
+func (h *Handler) revokeAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	keyID, err := parseID(c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeErrorResponse(c, http.StatusBadRequest, "invalid api key id")
+		return
+	}
+	if err := h.apiKeyService.RevokeAPIKey(ctx, keyID); err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			h.logger.ErrorfContext(ctx, "error when receiving api key to revoke: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
+			return
+		}
+		h.logger.ErrorfContext(ctx, "failed to revoke api key: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	writeSuccessResponse(c, http.StatusOK, "success")
+}
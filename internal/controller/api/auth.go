@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"net/http"
+	"strings"
+)
+
+const (
+	bearerPrefix = "bearer "
+	apiKeyHeader = "X-API-Key"
+)
+
+// authenticate verifies the api key or the bearer token of the request and stores the caller
+// identity in its context. The api key takes precedence when both are sent.
+func (h *Handler) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			h.authenticateAPIKey(c, key)
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		if len(header) < len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			c.Header("WWW-Authenticate", "Bearer")
+			writeProblem(c, http.StatusUnauthorized, auth.ErrUnauthenticated)
+			return
+		}
+
+		identity, err := h.tokenVerifier.Verify(strings.TrimSpace(header[len(bearerPrefix):]))
+		if err != nil {
+			h.logger.ErrorfContext(c.Request.Context(), "authentication failed: %v", err)
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeProblem(c, http.StatusUnauthorized, auth.ErrUnauthenticated)
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+		c.Next()
+	}
+}
+
+func (h *Handler) authenticateAPIKey(c *gin.Context, key string) {
+	identity, err := h.apiKeyService.AuthenticateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, auth.ErrUnauthenticated) {
+			h.logger.ErrorfContext(c.Request.Context(), "authentication failed: %v", err)
+			writeProblem(c, http.StatusUnauthorized, auth.ErrUnauthenticated)
+			return
+		}
+		h.logger.ErrorfContext(c.Request.Context(), "failed to authenticate api key: %v", err)
+		writeServerError(c, err)
+		return
+	}
+
+	c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+	c.Next()
+}
+
+// requireRole lets through only the callers that have the role or a higher one.
+func (h *Handler) requireRole(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := auth.IdentityFromContext(c.Request.Context())
+		if !ok {
+			writeProblem(c, http.StatusUnauthorized, auth.ErrUnauthenticated)
+			return
+		}
+		if !identity.HasRole(role) {
+			h.logger.ErrorfContext(c.Request.Context(), "%s is not allowed to %s %s", identity.Subject, c.Request.Method, c.FullPath())
+			writeProblem(c, http.StatusForbidden, auth.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,196 @@
+package api_test
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reader := auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleReader}}
+
+	tests := []struct {
+		name                    string
+		authorization           string
+		apiKey                  string
+		setup                   func(m mocks)
+		expectedStatus          int
+		expectedWWWAuthenticate string
+	}{
+		{
+			name:                    "no credentials",
+			expectedStatus:          http.StatusUnauthorized,
+			expectedWWWAuthenticate: "Bearer",
+		},
+		{
+			name:                    "not a bearer token",
+			authorization:           "Basic dXNlcjpwYXNz",
+			expectedStatus:          http.StatusUnauthorized,
+			expectedWWWAuthenticate: "Bearer",
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer expired",
+			setup: func(m mocks) {
+				m.tokenVerifier.EXPECT().Verify("expired").Return(auth.Identity{}, auth.ErrUnauthenticated)
+			},
+			expectedStatus:          http.StatusUnauthorized,
+			expectedWWWAuthenticate: `Bearer error="invalid_token"`,
+		},
+		{
+			name:          "valid token",
+			authorization: "bearer valid",
+			setup: func(m mocks) {
+				m.tokenVerifier.EXPECT().Verify("valid").Return(reader, nil)
+				m.peopleService.EXPECT().GetPeopleStats(gomock.Any(), gomock.Any()).Return(entity.PeopleStats{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:          "api key takes precedence over the token",
+			authorization: "Bearer valid",
+			apiKey:        "fio_key",
+			setup: func(m mocks) {
+				m.apiKeyService.EXPECT().AuthenticateAPIKey(gomock.Any(), "fio_key").Return(reader, nil)
+				m.peopleService.EXPECT().GetPeopleStats(gomock.Any(), gomock.Any()).Return(entity.PeopleStats{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "unknown api key",
+			apiKey: "fio_unknown",
+			setup: func(m mocks) {
+				m.apiKeyService.EXPECT().AuthenticateAPIKey(gomock.Any(), "fio_unknown").Return(auth.Identity{}, auth.ErrUnauthenticated)
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "api key lookup fails",
+			apiKey: "fio_key",
+			setup: func(m mocks) {
+				m.apiKeyService.EXPECT().AuthenticateAPIKey(gomock.Any(), "fio_key").Return(auth.Identity{}, errors.New("connection refused"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			if test.setup != nil {
+				test.setup(m)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/people/stats", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			if test.apiKey != "" {
+				req.Header.Set("X-API-Key", test.apiKey)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+			assert.Equal(t, test.expectedWWWAuthenticate, w.Header().Get("WWW-Authenticate"), "Test case %s failed: WWW-Authenticate not as expected", test.name)
+		})
+	}
+}
+
+func TestHandler_RequireRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name           string
+		roles          []auth.Role
+		method         string
+		path           string
+		setup          func(m mocks)
+		expectedStatus int
+	}{
+		{
+			name:   "reader reads",
+			roles:  []auth.Role{auth.RoleReader},
+			method: http.MethodGet,
+			path:   "/api/people/stats",
+			setup: func(m mocks) {
+				m.peopleService.EXPECT().GetPeopleStats(gomock.Any(), gomock.Any()).Return(entity.PeopleStats{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "reader deletes",
+			roles:          []auth.Role{auth.RoleReader},
+			method:         http.MethodDelete,
+			path:           "/api/person/delete/7",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "editor deletes",
+			roles:  []auth.Role{auth.RoleEditor},
+			method: http.MethodDelete,
+			path:   "/api/person/delete/7",
+			setup: func(m mocks) {
+				m.peopleService.EXPECT().DeletePersonData(gomock.Any(), 7, gomock.Any()).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "editor restores",
+			roles:          []auth.Role{auth.RoleEditor},
+			method:         http.MethodPost,
+			path:           "/api/person/7/restore",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "admin deletes",
+			roles:  []auth.Role{auth.RoleAdmin},
+			method: http.MethodDelete,
+			path:   "/api/person/delete/7",
+			setup: func(m mocks) {
+				m.peopleService.EXPECT().DeletePersonData(gomock.Any(), 7, gomock.Any()).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown role",
+			roles:          []auth.Role{"owner"},
+			method:         http.MethodGet,
+			path:           "/api/people/stats",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no roles",
+			method:         http.MethodGet,
+			path:           "/api/people/stats",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: test.roles}, nil)
+			if test.setup != nil {
+				test.setup(m)
+			}
+
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+		})
+	}
+}
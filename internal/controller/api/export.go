@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"strconv"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+	exportFormatXLSX   = "xlsx"
+
+	xlsxSheet = "Sheet1"
+)
+
+var ErrUnknownExportFormat = apperr.New(apperr.KindValidation, "unknown_export_format", "unknown export format, expected one of csv, ndjson, xlsx")
+
+var exportHeader = []string{"id", "name", "surname", "patronymic", "age", "gender", "nationality"}
+
+// peopleExporter writes people to the response one at a time in a particular file format.
+type peopleExporter interface {
+	Write(person entity.Person) error
+	Close() error
+}
+
+type exportFormat struct {
+	contentType string
+	newExporter func(w io.Writer) (peopleExporter, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	exportFormatCSV:    {contentType: "text/csv", newExporter: newCSVExporter},
+	exportFormatNDJSON: {contentType: ndjsonContentType, newExporter: newNDJSONExporter},
+	exportFormatXLSX:   {contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", newExporter: newXLSXExporter},
+}
+
+func personRecord(person entity.Person) []string {
+	return []string{
+		strconv.Itoa(person.ID),
+		person.Name,
+		person.Surname,
+		person.Patronymic,
+		strconv.Itoa(person.Age),
+		person.Gender,
+		person.Nationality,
+	}
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) (peopleExporter, error) {
+	e := &csvExporter{w: csv.NewWriter(w)}
+	if err := e.w.Write(exportHeader); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *csvExporter) Write(person entity.Person) error {
+	return e.w.Write(personRecord(person))
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONExporter(w io.Writer) (peopleExporter, error) {
+	return &ndjsonExporter{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonExporter) Write(person entity.Person) error {
+	return e.enc.Encode(person)
+}
+
+func (e *ndjsonExporter) Close() error {
+	return nil
+}
+
+// xlsxExporter relies on the excelize stream writer, which keeps only a small window of rows in
+// memory and spills the rest to a temporary file until the workbook is written out on Close.
+type xlsxExporter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExporter(w io.Writer) (peopleExporter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	header := make([]interface{}, len(exportHeader))
+	for i, column := range exportHeader {
+		header[i] = column
+	}
+
+	e := &xlsxExporter{w: w, file: file, stream: stream}
+	if err := e.writeRow(header); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExporter) Write(person entity.Person) error {
+	return e.writeRow([]interface{}{
+		person.ID,
+		person.Name,
+		person.Surname,
+		person.Patronymic,
+		person.Age,
+		person.Gender,
+		person.Nationality,
+	})
+}
+
+func (e *xlsxExporter) writeRow(values []interface{}) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.stream.SetRow(cell, values)
+}
+
+func (e *xlsxExporter) Close() error {
+	defer func() { _ = e.file.Close() }()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
@@ -0,0 +1,51 @@
+package api_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/controller/api"
+	"github.com/khasmag06/effective-mobile-test/pkg/metrics"
+	"time"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type mocks struct {
+	peopleService    *api.MockpeopleService
+	apiKeyService    *api.MockapiKeyService
+	personEnricher   *api.MockpersonEnricher
+	idempotencyStore *api.MockidempotencyStore
+	tokenVerifier    *api.MocktokenVerifier
+	rateLimiter      *api.MockrateLimiter
+}
+
+// newTestHandler builds the router with mocked dependencies, the rate limits are disabled
+// unless cfg sets them.
+func newTestHandler(ctrl *gomock.Controller, cfg config.HTTPConfig) (*api.Handler, mocks) {
+	m := mocks{
+		peopleService:    api.NewMockpeopleService(ctrl),
+		apiKeyService:    api.NewMockapiKeyService(ctrl),
+		personEnricher:   api.NewMockpersonEnricher(ctrl),
+		idempotencyStore: api.NewMockidempotencyStore(ctrl),
+		tokenVerifier:    api.NewMocktokenVerifier(ctrl),
+		rateLimiter:      api.NewMockrateLimiter(ctrl),
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = time.Second
+	}
+	if cfg.ExportTimeout == 0 {
+		cfg.ExportTimeout = time.Second
+	}
+
+	logger := api.NewMocklogger(ctrl)
+	logger.EXPECT().InfoContext(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().ErrorContext(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().ErrorfContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	h := api.NewHandler(cfg, m.peopleService, m.apiKeyService, m.personEnricher, m.idempotencyStore,
+		m.tokenVerifier, m.rateLimiter, api.NewMockhealthChecker(ctrl), metrics.New(), logger)
+	return h, m
+}
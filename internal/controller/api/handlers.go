@@ -1,165 +1,959 @@
 package api
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
 	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	defaultPaginationLimit = 10
 	defaultPageNumber      = 1
+	maxBulkCreateSize      = 10000
+
+	ndjsonContentType = "application/x-ndjson"
 )
 
 // @Tags People
 // @Summary addPerson
 // @Description create a new person
+// @Description Deprecated, use POST /api/v2/people instead.
 // @ID createPerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Accept  json
 // @Produce json
+// @Param Idempotency-Key header string false "Repeating the key returns the original response instead of creating the person again"
 // @Param input body entity.Person true "person info"
 // @Success 201 {object} successResponse
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 409 {object} problemDetails
+// @Failure 422 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Deprecated
 // @Router /person/create [post]
 func (h *Handler) addPerson(c *gin.Context) {
-	ctx := context.Background()
-	var personReq entity.Person
-	if err := c.Bind(&personReq); err != nil {
-		h.logger.Errorf("json body binding error: %v", err)
+	if _, ok := h.createPerson(c); !ok {
+		return
+	}
+
+	writeSuccessResponse(c, http.StatusCreated, "success")
+
+}
+
+// @Tags People
+// @Summary addPeople
+// @Description create people in bulk from a JSON array or NDJSON (one person per line).
+// @Description Every item is validated separately, the valid ones are inserted in a single batch.
+// @ID createPeople
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Accept  application/x-ndjson
+// @Produce json
+// @Param Idempotency-Key header string false "Repeating the key returns the original result instead of creating the people again"
+// @Param input body []entity.Person true "people info"
+// @Success 200 {object} entity.BulkCreateResult
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 409 {object} problemDetails
+// @Failure 422 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /people/bulk [post]
+func (h *Handler) addPeople(c *gin.Context) {
+	ctx := c.Request.Context()
+	people, err := decodePeople(c.Request.Body, c.ContentType() == ndjsonContentType)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "body decoding error: %v", err)
 		writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
 		return
 	}
-	if err := h.Validate(personReq); err != nil {
-		h.logger.Errorf("validation err: %v", err)
-		writeErrorResponse(c, http.StatusBadRequest, err.Error())
+	if len(people) == 0 || len(people) > maxBulkCreateSize {
+		writeErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("number of people must be between 1 and %d", maxBulkCreateSize))
+		return
+	}
+
+	result, err := h.peopleService.CreatePeople(ctx, people)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to create people data: %v", err.Error())
+		writeServerError(c, err)
 		return
 	}
-	if err := h.peopleService.CreatePerson(ctx, personReq); err != nil {
-		h.logger.Errorf("failed to create person data: %v", err.Error())
-		writeErrorResponse(c, http.StatusInternalServerError, "internal server error")
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Tags People
+// @Summary importPeople
+// @Description import people from an uploaded CSV file with a header row or a JSON array of objects.
+// @Description Columns are matched to the person fields by name unless mapped explicitly. Every row goes through
+// @Description the same validation as a single person, dryRun reports the result without writing anything.
+// @ID importPeople
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or JSON file"
+// @Param format query string false "File format: csv or json (default is the file extension)"
+// @Param mapping query string false "Column to field mapping, e.g. 'Имя:name,Фамилия:surname'"
+// @Param enrich query bool false "Fill in missing age, gender and nationality from the enrichment APIs"
+// @Param dryRun query bool false "Only validate the rows"
+// @Success 200 {object} entity.ImportReport
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /people/import [post]
+func (h *Handler) importPeople(c *gin.Context) {
+	ctx := c.Request.Context()
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "form file error: %v", err)
+		writeErrorResponse(c, http.StatusBadRequest, "file is required")
 		return
 	}
+	format := c.DefaultQuery("format", strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")))
+	mapping, err := parseImportMapping(c.Query("mapping"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	enrich, _ := strconv.ParseBool(c.Query("enrich"))
 
-	writeSuccessResponse(c, http.StatusCreated, "success")
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to open uploaded file: %v", err)
+		writeServerError(c, err)
+		return
+	}
+	defer file.Close()
 
+	rows, err := readImportRows(file, format, mapping)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "import file reading error: %v", err)
+		writeProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(rows) > maxBulkCreateSize {
+		writeErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("number of rows must be at most %d", maxBulkCreateSize))
+		return
+	}
+	if enrich {
+		h.enrichImportRows(ctx, rows)
+	}
+
+	people := make([]entity.Person, 0, len(rows))
+	rowIndexes := make([]int, 0, len(rows))
+	for i, row := range rows {
+		if row.err == nil {
+			people = append(people, row.person)
+			rowIndexes = append(rowIndexes, i)
+		}
+	}
+
+	var result entity.BulkCreateResult
+	if dryRun || len(people) == 0 {
+		result = h.peopleService.ValidatePeople(people)
+	} else {
+		result, err = h.peopleService.CreatePeople(ctx, people)
+		if err != nil {
+			h.logger.ErrorfContext(ctx, "failed to import people data: %v", err.Error())
+			writeServerError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, newImportReport(rows, rowIndexes, result, dryRun))
 }
 
 // @Tags People
 // @Summary get list of people
-// @Description get a list of people with pagination and sorting
+// @Description get a list of people with pagination, sorting and filtering.
+// @Description Passing the cursor parameter (empty for the first page) switches to keyset pagination,
+// @Description the response is then an object with the people and the next/previous page cursors.
+// @Description Deprecated, use GET /api/v2/people instead.
 // @ID getPeople
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number (default is 1)"
+// @Param cursor query string false "Opaque page cursor, enables cursor pagination"
 // @Param limit query int false "Number of items per page (default is 10)"
 // @Param sortBy query string false "Sorting field (default is 'date')"
 // @Param sortOrder query string false "Sorting order (default is 'asc')"
+// @Param name query string false "Name prefix"
+// @Param surname query string false "Surname prefix"
+// @Param patronymic query string false "Patronymic prefix"
+// @Param ageFrom query int false "Minimum age"
+// @Param ageTo query int false "Maximum age"
+// @Param gender query string false "Gender (male or female)"
+// @Param nationality query []string false "Nationalities" collectionFormat(multi)
+// @Param createdFrom query string false "Created at or after (RFC 3339)"
+// @Param createdTo query string false "Created at or before (RFC 3339)"
 // @Success 200 {array} entity.Person "List of people"
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Deprecated
 // @Router /people/get [get]
 func (h *Handler) getPeople(c *gin.Context) {
-	page, err := strconv.Atoi(c.Query("page"))
-	if err != nil || page <= 0 {
-		page = defaultPageNumber
+	query, ok := h.bindPeopleQuery(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		peoplePage, err := h.peopleService.GetPeopleByCursor(ctx, cursor, query.limit, query.sortBy, query.sortOrder, query.filter)
+		if err != nil {
+			if errors.Is(err, repoerrs.ErrInvalidCursor) {
+				h.logger.ErrorfContext(ctx, "error when receiving people page: %v", err.Error())
+				writeProblem(c, http.StatusBadRequest, err)
+				return
+			}
+			h.logger.ErrorfContext(ctx, "failed to fetch people data: %v", err.Error())
+			writeServerError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, peoplePage)
+		return
+	}
+
+	people, err := h.peopleService.GetPeople(ctx, query.page, query.limit, query.sortBy, query.sortOrder, query.filter)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to fetch people data: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, people)
+}
+
+// @Tags People
+// @Summary export people
+// @Description stream all people matching the filters as CSV, NDJSON or XLSX, ignoring the page size limit
+// @ID exportPeople
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format: csv, ndjson or xlsx (default is 'csv')"
+// @Param sortBy query string false "Sorting field (default is 'date')"
+// @Param sortOrder query string false "Sorting order (default is 'asc')"
+// @Param name query string false "Name prefix"
+// @Param surname query string false "Surname prefix"
+// @Param patronymic query string false "Patronymic prefix"
+// @Param ageFrom query int false "Minimum age"
+// @Param ageTo query int false "Maximum age"
+// @Param gender query string false "Gender (male or female)"
+// @Param nationality query []string false "Nationalities" collectionFormat(multi)
+// @Param createdFrom query string false "Created at or after (RFC 3339)"
+// @Param createdTo query string false "Created at or before (RFC 3339)"
+// @Success 200 {file} file "people export"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /people/export [get]
+func (h *Handler) exportPeople(c *gin.Context) {
+	ctx := c.Request.Context()
+	formatName := c.DefaultQuery("format", exportFormatCSV)
+	format, ok := exportFormats[formatName]
+	if !ok {
+		h.logger.ErrorContext(ctx, ErrUnknownExportFormat.Error())
+		writeProblem(c, http.StatusBadRequest, ErrUnknownExportFormat)
+		return
+	}
+	query, ok := h.bindPeopleQuery(c)
+	if !ok {
+		return
+	}
+
+	// an export may take far longer than the server write timeout allows for regular responses
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.ErrorfContext(ctx, "failed to reset write deadline: %v", err)
+	}
+
+	exporter, err := format.newExporter(c.Writer)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to start people export: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="people.%s"`, formatName))
+
+	err = h.peopleService.ExportPeople(ctx, query.sortBy, query.sortOrder, query.filter, exporter.Write)
+	if closeErr := exporter.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to export people data: %v", err.Error())
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			writeServerError(c, err)
+			return
+		}
+		// the body is partially sent, the client can only notice the broken stream
+		c.Abort()
+	}
+}
+
+// @Tags People
+// @Summary search people
+// @Description typo-tolerant, case-insensitive search by name, surname and patronymic, ranked by similarity
+// @ID searchPeople
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param query query string true "Search query"
+// @Param limit query int false "Number of results (default is 10)"
+// @Success 200 {array} entity.PersonSearchResult "Matching people"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /people/search [get]
+func (h *Handler) searchPeople(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("query"))
+	if query == "" {
+		writeErrorResponse(c, http.StatusBadRequest, "query is required")
+		return
 	}
 	limit, err := strconv.Atoi(c.Query("limit"))
 	if err != nil || limit <= 0 {
 		limit = defaultPaginationLimit
 	}
-	sortBy := c.DefaultQuery("sortBy", "date")
-	sortOrder := c.DefaultQuery("sortOrder", "asc")
+	ctx := c.Request.Context()
 
-	ctx := context.Background()
+	results, err := h.peopleService.SearchPeople(ctx, query, limit)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to search people data: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
 
-	people, err := h.peopleService.GetPeople(ctx, page, limit, sortBy, sortOrder)
+	c.JSON(http.StatusOK, results)
+}
+
+// @Tags People
+// @Summary people statistics
+// @Description counts by gender, the most common nationalities with the average and median age of their people,
+// @Description an age histogram and the number of people created per period, all over the people matching the filters
+// @ID getPeopleStats
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param topNationalities query int false "Number of the most common nationalities (default is 10)"
+// @Param ageBuckets query []int false "Ages the histogram buckets after the first one start at (default is 18,25,35,45,55,65)" collectionFormat(csv)
+// @Param growthInterval query string false "Growth period: day, week, month or year (default is 'month')"
+// @Param name query string false "Name prefix"
+// @Param surname query string false "Surname prefix"
+// @Param patronymic query string false "Patronymic prefix"
+// @Param ageFrom query int false "Minimum age"
+// @Param ageTo query int false "Maximum age"
+// @Param gender query string false "Gender (male or female)"
+// @Param nationality query []string false "Nationalities" collectionFormat(multi)
+// @Param createdFrom query string false "Created at or after (RFC 3339)"
+// @Param createdTo query string false "Created at or before (RFC 3339)"
+// @Success 200 {object} entity.PeopleStats
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /people/stats [get]
+func (h *Handler) getPeopleStats(c *gin.Context) {
+	query, ok := h.bindPeopleQuery(c)
+	if !ok {
+		return
+	}
+	statsQuery := entity.PeopleStatsQuery{Filter: query.filter, GrowthInterval: c.Query("growthInterval")}
+	if top := c.Query("topNationalities"); top != "" {
+		var err error
+		if statsQuery.TopNationalities, err = strconv.Atoi(top); err != nil {
+			writeErrorResponse(c, http.StatusBadRequest, "invalid topNationalities, expected a number")
+			return
+		}
+	}
+	if buckets := c.Query("ageBuckets"); buckets != "" {
+		for _, bucket := range strings.Split(buckets, ",") {
+			age, err := strconv.Atoi(strings.TrimSpace(bucket))
+			if err != nil {
+				writeErrorResponse(c, http.StatusBadRequest, "invalid ageBuckets, expected ages separated by commas")
+				return
+			}
+			statsQuery.AgeBuckets = append(statsQuery.AgeBuckets, age)
+		}
+	}
+	ctx := c.Request.Context()
+
+	stats, err := h.peopleService.GetPeopleStats(ctx, statsQuery)
 	if err != nil {
-		h.logger.Errorf("failed to fetch people data: %v", err.Error())
-		writeErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		var validationErr *validator.ValidationError
+		if errors.As(err, &validationErr) {
+			h.logger.ErrorfContext(ctx, "validation err: %v", err)
+			writeProblem(c, http.StatusBadRequest, err)
+			return
+		}
+		h.logger.ErrorfContext(ctx, "failed to compute people stats: %v", err.Error())
+		writeServerError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, people)
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Tags People
+// @Summary getPerson
+// @Description get a person by id
+// @Description Deprecated, use GET /api/v2/people/{id} instead.
+// @ID getPerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param id path int64 true "ID of the person to get"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Deprecated
+// @Router /person/{id} [get]
+func (h *Handler) getPerson(c *gin.Context) {
+	ctx := c.Request.Context()
+	personID, err := parseID(c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	person, err := h.peopleService.GetPersonByID(ctx, personID)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			h.logger.ErrorfContext(ctx, "error when receiving person data: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
+			return
+		}
+		h.logger.ErrorfContext(ctx, "failed to fetch person data: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(person.Version))
+	c.JSON(http.StatusOK, person)
 }
 
 // @Tags People
 // @Summary updatePerson
 // @Description update a person
+// @Description Deprecated, use PUT /api/v2/people/{id} instead.
 // @ID updatePerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Accept  json
 // @Produce json
 // @Param id path int64 true "ID of the person to update"
-// @Param input body entity.Person true "person info"
+// @Param If-Match header string false "ETag of the person version the update is based on"
+// @Param input body entity.Person true "person info, a non-zero version works like If-Match"
 // @Success 200 {object} successResponse
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 409 {object} problemDetails
+// @Failure 412 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Deprecated
 // @Router /person/update/{id} [put]
 func (h *Handler) updatePerson(c *gin.Context) {
+	if _, ok := h.replacePerson(c); !ok {
+		return
+	}
+
+	writeSuccessResponse(c, http.StatusOK, "success")
+}
+
+// @Tags People
+// @Summary patchPerson
+// @Description partially update a person with a JSON Merge Patch (RFC 7396).
+// @Description Omitted fields are left unchanged, fields set to null are cleared.
+// @Description Deprecated, use PATCH /api/v2/people/{id} instead.
+// @ID patchPerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Accept  application/merge-patch+json
+// @Produce json
+// @Param id path int64 true "ID of the person to patch"
+// @Param If-Match header string false "ETag of the person version the patch is based on"
+// @Param input body entity.PersonPatch true "fields to change"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 412 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Deprecated
+// @Router /person/{id} [patch]
+func (h *Handler) patchPerson(c *gin.Context) {
+	ctx := c.Request.Context()
 	personID, err := parseID(c.Param("id"))
 	if err != nil {
-		h.logger.Error(err.Error())
-		writeErrorResponse(c, http.StatusBadRequest, err.Error())
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
 		return
 	}
-	ctx := context.Background()
-	var personReq entity.Person
-	if err := c.Bind(&personReq); err != nil {
-		h.logger.Errorf("json body binding error: %v", err)
+	var patch entity.PersonPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		h.logger.ErrorfContext(ctx, "json body binding error: %v", err)
 		writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
 		return
 	}
-	if err := h.Validate(personReq); err != nil {
-		h.logger.Errorf("validation err: %v", err)
-		writeErrorResponse(c, http.StatusBadRequest, err.Error())
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
 		return
 	}
-	if err := h.peopleService.UpdatePersonData(ctx, personID, personReq); err != nil {
-		if errors.Is(err, repoerrs.ErrNotFound) {
-			h.logger.Errorf("error when receiving update data: %v", err.Error())
-			writeErrorResponse(c, http.StatusNotFound, err.Error())
-			return
+	person, err := h.peopleService.PatchPerson(ctx, personID, patch, expectedVersion)
+	if err != nil {
+		var validationErr *validator.ValidationError
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			h.logger.ErrorfContext(ctx, "error when receiving patch data: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
+		case errors.Is(err, repoerrs.ErrConflict):
+			h.logger.ErrorfContext(ctx, "error when receiving patch data: %v", err.Error())
+			writeProblem(c, http.StatusPreconditionFailed, err)
+		case errors.As(err, &validationErr):
+			h.logger.ErrorfContext(ctx, "validation err: %v", err)
+			writeProblem(c, http.StatusBadRequest, err)
+		default:
+			h.logger.ErrorfContext(ctx, "failed to patch person data: %v", err.Error())
+			writeServerError(c, err)
 		}
-		h.logger.Errorf("failed to update person data: %v", err.Error())
-		writeErrorResponse(c, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
-	writeSuccessResponse(c, http.StatusOK, "success")
+	c.Header("ETag", etag(person.Version))
+	c.JSON(http.StatusOK, person)
 }
 
 // @Tags People
 // @Summary deletePerson
-// @Description delete a person
+// @Description move a person to the trash, it can be restored until the retention period passes
+// @Description Deprecated, use DELETE /api/v2/people/{id} instead.
 // @ID deletePerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Accept  json
 // @Produce json
 // @Param id path int64 true "ID of the person to delete"
+// @Param If-Match header string false "ETag of the person version the deletion is based on"
 // @Success 200 {object} successResponse
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 412 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Deprecated
 // @Router /person/delete/{id} [delete]
 func (h *Handler) deletePerson(c *gin.Context) {
+	if !h.removePerson(c) {
+		return
+	}
+	writeSuccessResponse(c, http.StatusOK, "success")
+}
+
+// @Tags People
+// @Summary getTrash
+// @Description get deleted people that have not been purged yet, most recently deleted first
+// @ID getTrash
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (default is 1)"
+// @Param limit query int false "Number of records per page (default is 10)"
+// @Success 200 {array} entity.Person "List of deleted people"
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /people/trash [get]
+func (h *Handler) getTrash(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page <= 0 {
+		page = defaultPageNumber
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPaginationLimit
+	}
+	ctx := c.Request.Context()
+
+	people, err := h.peopleService.GetDeletedPeople(ctx, page, limit)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to fetch deleted people data: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, people)
+}
+
+// @Tags People
+// @Summary restorePerson
+// @Description restore a deleted person from the trash
+// @ID restorePerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID of the person to restore"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /person/{id}/restore [post]
+func (h *Handler) restorePerson(c *gin.Context) {
+	ctx := c.Request.Context()
 	personID, err := parseID(c.Param("id"))
 	if err != nil {
-		h.logger.Error(err.Error())
-		writeErrorResponse(c, http.StatusBadRequest, err.Error())
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
 		return
 	}
-	ctx := context.Background()
-	if err := h.peopleService.DeletePersonData(ctx, personID); err != nil {
+	person, err := h.peopleService.RestorePerson(ctx, personID)
+	if err != nil {
 		if errors.Is(err, repoerrs.ErrNotFound) {
-			h.logger.Errorf("error when receiving data to delete: %v", err.Error())
-			writeErrorResponse(c, http.StatusNotFound, err.Error())
+			h.logger.ErrorfContext(ctx, "error when receiving data to restore: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
 			return
 		}
-		h.logger.Errorf("failed to delete person data: %v", err.Error())
-		writeErrorResponse(c, http.StatusInternalServerError, "internal server error")
+		h.logger.ErrorfContext(ctx, "failed to restore person data: %v", err.Error())
+		writeServerError(c, err)
 		return
 	}
-	writeSuccessResponse(c, http.StatusOK, "success")
+
+	c.Header("ETag", etag(person.Version))
+	c.JSON(http.StatusOK, person)
+}
+
+// @Tags People
+// @Summary getPersonHistory
+// @Description get the recorded changes of a person, newest first, with the actor, source and changed fields
+// @ID getPersonHistory
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID of the person"
+// @Success 200 {array} entity.PersonRevision
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /person/{id}/history [get]
+func (h *Handler) getPersonHistory(c *gin.Context) {
+	ctx := c.Request.Context()
+	personID, err := parseID(c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	history, err := h.peopleService.GetPersonHistory(ctx, personID)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to fetch person history: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// @Tags People
+// @Summary revertPerson
+// @Description overwrite a person with the data of one of its revisions, the revert is recorded as a new revision
+// @ID revertPerson
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID of the person to revert"
+// @Param revision path int true "Revision to restore the data of"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 409 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /person/{id}/history/{revision}/revert [post]
+func (h *Handler) revertPerson(c *gin.Context) {
+	ctx := c.Request.Context()
+	personID, err := parseID(c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		h.logger.ErrorfContext(ctx, "invalid revision: %s", c.Param("revision"))
+		writeErrorResponse(c, http.StatusBadRequest, "invalid revision")
+		return
+	}
+	person, err := h.peopleService.RevertPerson(ctx, personID, revision)
+	if err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound), errors.Is(err, repoerrs.ErrRevisionNotFound):
+			h.logger.ErrorfContext(ctx, "error when receiving data to revert: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
+		case errors.Is(err, repoerrs.ErrConflict):
+			h.logger.ErrorfContext(ctx, "error when receiving data to revert: %v", err.Error())
+			writeProblem(c, http.StatusConflict, err)
+		default:
+			h.logger.ErrorfContext(ctx, "failed to revert person data: %v", err.Error())
+			writeServerError(c, err)
+		}
+		return
+	}
+
+	c.Header("ETag", etag(person.Version))
+	c.JSON(http.StatusOK, person)
+}
+
+type peopleQuery struct {
+	page      int
+	limit     int
+	sortBy    string
+	sortOrder string
+	filter    entity.PeopleFilter
+}
+
+// bindPeopleQuery reads the pagination, sorting and filter parameters shared by the people listings.
+// On invalid parameters it writes the error response itself and reports false.
+func (h *Handler) bindPeopleQuery(c *gin.Context) (peopleQuery, bool) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page <= 0 {
+		page = defaultPageNumber
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPaginationLimit
+	}
+	query := peopleQuery{
+		page:      page,
+		limit:     limit,
+		sortBy:    c.DefaultQuery("sortBy", "date"),
+		sortOrder: c.DefaultQuery("sortOrder", "asc"),
+	}
+
+	if err := c.ShouldBindQuery(&query.filter); err != nil {
+		h.logger.ErrorfContext(c.Request.Context(), "query binding error: %v", err)
+		writeErrorResponse(c, http.StatusBadRequest, "invalid filter parameters")
+		return peopleQuery{}, false
+	}
+	if err := h.Validate(query.filter); err != nil {
+		h.logger.ErrorfContext(c.Request.Context(), "validation err: %v", err)
+		writeProblem(c, http.StatusBadRequest, err)
+		return peopleQuery{}, false
+	}
+
+	return query, true
+}
+
+// createPerson stores the person from the request body. On failure it writes the error response
+// itself and reports false.
+func (h *Handler) createPerson(c *gin.Context) (entity.Person, bool) {
+	ctx := c.Request.Context()
+	var personReq entity.Person
+	if err := c.Bind(&personReq); err != nil {
+		h.logger.ErrorfContext(ctx, "json body binding error: %v", err)
+		writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
+		return entity.Person{}, false
+	}
+	if err := h.Validate(personReq); err != nil {
+		h.logger.ErrorfContext(ctx, "validation err: %v", err)
+		writeProblem(c, http.StatusBadRequest, err)
+		return entity.Person{}, false
+	}
+	person, err := h.peopleService.CreatePerson(ctx, personReq)
+	if err != nil {
+		h.logger.ErrorfContext(ctx, "failed to create person data: %v", err.Error())
+		writeServerError(c, err)
+		return entity.Person{}, false
+	}
+
+	return person, true
+}
+
+// replacePerson overwrites the person of the id path parameter with the request body. On failure
+// it writes the error response itself and reports false.
+func (h *Handler) replacePerson(c *gin.Context) (entity.Person, bool) {
+	ctx := c.Request.Context()
+	personID, err := parseID(c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return entity.Person{}, false
+	}
+	var personReq entity.Person
+	if err := c.Bind(&personReq); err != nil {
+		h.logger.ErrorfContext(ctx, "json body binding error: %v", err)
+		writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
+		return entity.Person{}, false
+	}
+	if err := h.Validate(personReq); err != nil {
+		h.logger.ErrorfContext(ctx, "validation err: %v", err)
+		writeProblem(c, http.StatusBadRequest, err)
+		return entity.Person{}, false
+	}
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return entity.Person{}, false
+	}
+	conflictStatus := http.StatusPreconditionFailed
+	if expectedVersion == 0 && personReq.Version != 0 {
+		expectedVersion, conflictStatus = personReq.Version, http.StatusConflict
+	}
+	person, err := h.peopleService.UpdatePersonData(ctx, personID, personReq, expectedVersion)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			h.logger.ErrorfContext(ctx, "error when receiving update data: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
+			return entity.Person{}, false
+		}
+		if errors.Is(err, repoerrs.ErrConflict) {
+			h.logger.ErrorfContext(ctx, "error when receiving update data: %v", err.Error())
+			writeProblem(c, conflictStatus, err)
+			return entity.Person{}, false
+		}
+		h.logger.ErrorfContext(ctx, "failed to update person data: %v", err.Error())
+		writeServerError(c, err)
+		return entity.Person{}, false
+	}
+
+	return person, true
+}
+
+// removePerson moves the person of the id path parameter to the trash. On failure it writes
+// the error response itself and reports false.
+func (h *Handler) removePerson(c *gin.Context) bool {
+	ctx := c.Request.Context()
+	personID, err := parseID(c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return false
+	}
+	expectedVersion, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, err.Error())
+		writeProblem(c, http.StatusBadRequest, err)
+		return false
+	}
+	if err := h.peopleService.DeletePersonData(ctx, personID, expectedVersion); err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			h.logger.ErrorfContext(ctx, "error when receiving data to delete: %v", err.Error())
+			writeProblem(c, http.StatusNotFound, err)
+			return false
+		}
+		if errors.Is(err, repoerrs.ErrConflict) {
+			h.logger.ErrorfContext(ctx, "error when receiving data to delete: %v", err.Error())
+			writeProblem(c, http.StatusPreconditionFailed, err)
+			return false
+		}
+		h.logger.ErrorfContext(ctx, "failed to delete person data: %v", err.Error())
+		writeServerError(c, err)
+		return false
+	}
+
+	return true
+}
+
+// decodePeople reads either a JSON array of people or NDJSON with a person per line.
+func decodePeople(body io.Reader, ndjson bool) ([]entity.Person, error) {
+	var people []entity.Person
+	if !ndjson {
+		if err := json.NewDecoder(body).Decode(&people); err != nil {
+			return nil, err
+		}
+		return people, nil
+	}
+
+	decoder := json.NewDecoder(body)
+	for {
+		var person entity.Person
+		if err := decoder.Decode(&person); err != nil {
+			if errors.Is(err, io.EOF) {
+				return people, nil
+			}
+			return nil, fmt.Errorf("item %d: %w", len(people), err)
+		}
+		people = append(people, person)
+	}
 }
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/pkg/health"
+	"net/http"
+)
+
+// liveness reports that the process is up and serving, it does not look at the dependencies.
+func (h *Handler) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// readiness reports the state of every dependency. A degraded service keeps receiving traffic,
+// a service that is down or shutting down answers 503.
+func (h *Handler) readiness(c *gin.Context) {
+	report := h.healthChecker.Check(c.Request.Context())
+	if !report.Ready() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
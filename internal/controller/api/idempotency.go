@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/controller/graph"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/idempotency"
+	"io"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// idempotent makes repeated requests with the same Idempotency-Key header return the response of the
+// first one instead of executing again. Keys are kept per caller, a key reused with a different body
+// is rejected. Requests without the header are passed through, responses with a server error are not remembered.
+func (h *Handler) idempotent(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeErrorResponse(c, http.StatusBadRequest, "idempotency key is too long")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			h.logger.ErrorfContext(ctx, "failed to read request body: %v", err)
+			writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		identity, _ := auth.IdentityFromContext(ctx)
+		key = idempotency.Key(scope, identity.Subject, key)
+		hash := requestHash(c.Request, body)
+		stored, err := h.idempotencyStore.Begin(ctx, key, hash)
+		if err != nil {
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused):
+				writeProblem(c, http.StatusUnprocessableEntity, err)
+			case errors.Is(err, idempotency.ErrRequestInProgress):
+				writeProblem(c, http.StatusConflict, err)
+			default:
+				h.logger.ErrorfContext(ctx, "failed to check idempotency key: %v", err)
+				writeServerError(c, err)
+			}
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			c.Header("Idempotent-Replayed", "true")
+			if stored.Location != "" {
+				c.Header("Location", stored.Location)
+			}
+			contentType := stored.ContentType
+			if contentType == "" {
+				contentType = gin.MIMEJSON
+			}
+			c.Data(stored.Status, contentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// The client may be gone or the deadline passed by now, the key has to be finished anyway.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotency.FinishTimeout)
+		defer cancel()
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := h.idempotencyStore.Abort(ctx, key); err != nil {
+				h.logger.ErrorContext(ctx, err.Error())
+			}
+			return
+		}
+		response := entity.IdempotentResponse{
+			RequestHash: hash,
+			Status:      c.Writer.Status(),
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Location:    c.Writer.Header().Get("Location"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := h.idempotencyStore.Complete(ctx, key, response); err != nil {
+			h.logger.ErrorContext(ctx, err.Error())
+		}
+	}
+}
+
+// withIdempotencyKey hands the Idempotency-Key header over to the GraphQL mutations.
+func withIdempotencyKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(idempotencyKeyHeader); key != "" {
+			c.Request = c.Request.WithContext(graph.WithIdempotencyKey(c.Request.Context(), key))
+		}
+		c.Next()
+	}
+}
+
+// requestHash identifies the request a key was used with by its method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,373 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/idempotency"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const personBody = `{"name":"Ivan","surname":"Ivanov","age":30,"gender":"male","nationality":"RU"}`
+
+var createdPerson = entity.Person{ID: 7, Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU", Version: 1}
+
+func TestHandler_Idempotent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name             string
+		subject          string
+		idempotencyKey   string
+		body             string
+		setup            func(m mocks, completed *entity.IdempotentResponse)
+		expectedStatus   int
+		expectedHeaders  map[string]string
+		expectedBody     string
+		expectedComplete *entity.IdempotentResponse
+	}{
+		{
+			name:    "request without a key",
+			subject: "user-1",
+			body:    personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(createdPerson, nil)
+			},
+			expectedStatus:  http.StatusCreated,
+			expectedHeaders: map[string]string{"Idempotent-Replayed": ""},
+		},
+		{
+			name:           "key is too long",
+			subject:        "user-1",
+			idempotencyKey: strings.Repeat("k", 256),
+			body:           personBody,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "first request is remembered",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, completed *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), "people:create:user-1:k1", gomock.Any()).Return(nil, nil)
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(createdPerson, nil)
+				m.idempotencyStore.EXPECT().Complete(gomock.Any(), "people:create:user-1:k1", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, response entity.IdempotentResponse) error {
+						*completed = response
+						return nil
+					})
+			},
+			expectedStatus:  http.StatusCreated,
+			expectedHeaders: map[string]string{"Idempotent-Replayed": "", "Location": "/api/v2/people/7"},
+			expectedComplete: &entity.IdempotentResponse{
+				Status:      http.StatusCreated,
+				ContentType: "application/json; charset=utf-8",
+				Location:    "/api/v2/people/7",
+			},
+		},
+		{
+			name:           "key is kept per caller",
+			subject:        "user:1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), "people:create:user%3A1:k1", gomock.Any()).Return(nil, nil)
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(createdPerson, nil)
+				m.idempotencyStore.EXPECT().Complete(gomock.Any(), "people:create:user%3A1:k1", gomock.Any()).Return(nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "repeated request is replayed",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), "people:create:user-1:k1", gomock.Any()).Return(&entity.IdempotentResponse{
+					Status:   http.StatusCreated,
+					Location: "/api/v2/people/7",
+					Body:     []byte(`{"id":7}`),
+				}, nil)
+			},
+			expectedStatus:  http.StatusCreated,
+			expectedHeaders: map[string]string{"Idempotent-Replayed": "true", "Location": "/api/v2/people/7", "Content-Type": "application/json"},
+			expectedBody:    `{"id":7}`,
+		},
+		{
+			name:           "request in progress",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, idempotency.ErrRequestInProgress)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "key reused with a different body",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, idempotency.ErrKeyReused)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "store fails",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "client error is remembered",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           `{"name":"Ivan"}`,
+			setup: func(m mocks, completed *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+				m.idempotencyStore.EXPECT().Complete(gomock.Any(), "people:create:user-1:k1", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, response entity.IdempotentResponse) error {
+						*completed = response
+						return nil
+					})
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedComplete: &entity.IdempotentResponse{Status: http.StatusBadRequest, ContentType: "application/problem+json"},
+		},
+		{
+			name:           "server error releases the key",
+			subject:        "user-1",
+			idempotencyKey: "k1",
+			body:           personBody,
+			setup: func(m mocks, _ *entity.IdempotentResponse) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(entity.Person{}, errors.New("connection refused"))
+				m.idempotencyStore.EXPECT().Abort(gomock.Any(), "people:create:user-1:k1").Return(nil)
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: test.subject, Roles: []auth.Role{auth.RoleEditor}}, nil)
+			var completed entity.IdempotentResponse
+			if test.setup != nil {
+				test.setup(m, &completed)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/people", strings.NewReader(test.body))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", "application/json")
+			if test.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", test.idempotencyKey)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+			for header, expected := range test.expectedHeaders {
+				assert.Equal(t, expected, w.Header().Get(header), "Test case %s failed: Header %s not as expected", test.name, header)
+			}
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, w.Body.String(), "Test case %s failed: Body not as expected", test.name)
+			}
+			if test.expectedComplete != nil {
+				assert.NotEmpty(t, completed.RequestHash, "Test case %s failed: Request hash not as expected", test.name)
+				assert.Equal(t, w.Body.String(), string(completed.Body), "Test case %s failed: Remembered body not as expected", test.name)
+				completed.RequestHash, completed.Body = "", nil
+				assert.Equal(t, *test.expectedComplete, completed, "Test case %s failed: Remembered response not as expected", test.name)
+			}
+		})
+	}
+}
+
+func TestHandler_IdempotentRequestHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The store tells a reused key apart by the hash, so it has to follow the body
+	hashOf := func(body string) string {
+		h, m := newTestHandler(ctrl, config.HTTPConfig{})
+		m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+		var hash string
+		m.idempotencyStore.EXPECT().Begin(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, requestHash string) (*entity.IdempotentResponse, error) {
+				hash = requestHash
+				return nil, idempotency.ErrRequestInProgress
+			})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/people", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("Idempotency-Key", "k1")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		return hash
+	}
+
+	assert.Equal(t, hashOf(personBody), hashOf(personBody))
+	assert.NotEqual(t, hashOf(personBody), hashOf(strings.Replace(personBody, "Ivan", "Petr", 1)))
+}
+
+func TestHandler_IdempotentGraphQL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const (
+		mutation = `{"query":"mutation { createPerson(input: {name: \"Ivan\", surname: \"Ivanov\", age: 30, gender: \"male\", nationality: \"RU\"}) { id name } }"}`
+		key      = "graphql:createPerson:user-1:k1"
+	)
+
+	tests := []struct {
+		name           string
+		idempotencyKey string
+		setup          func(m mocks)
+		expectedBody   string
+	}{
+		{
+			name: "mutation without a key",
+			setup: func(m mocks) {
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(createdPerson, nil)
+			},
+			expectedBody: `{"data":{"createPerson":{"id":7,"name":"Ivan"}}}`,
+		},
+		{
+			name:           "first mutation is remembered",
+			idempotencyKey: "k1",
+			setup: func(m mocks) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), key, gomock.Any()).Return(nil, nil)
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(createdPerson, nil)
+				m.idempotencyStore.EXPECT().Complete(gomock.Any(), key, gomock.Any()).Return(nil)
+			},
+			expectedBody: `{"data":{"createPerson":{"id":7,"name":"Ivan"}}}`,
+		},
+		{
+			name:           "repeated mutation is replayed",
+			idempotencyKey: "k1",
+			setup: func(m mocks) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), key, gomock.Any()).Return(&entity.IdempotentResponse{
+					Status: http.StatusOK,
+					Body:   []byte(`{"id":5,"name":"Ivan","surname":"Ivanov","age":30,"gender":"male","nationality":"RU"}`),
+				}, nil)
+			},
+			expectedBody: `{"data":{"createPerson":{"id":5,"name":"Ivan"}}}`,
+		},
+		{
+			name:           "mutation in progress",
+			idempotencyKey: "k1",
+			setup: func(m mocks) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), key, gomock.Any()).Return(nil, idempotency.ErrRequestInProgress)
+			},
+			expectedBody: `{"errors":[{"message":"a request with this idempotency key is still in progress","path":["createPerson"],"extensions":{"code":"CONFLICT"}}],"data":{"createPerson":null}}`,
+		},
+		{
+			name:           "key reused with a different input",
+			idempotencyKey: "k1",
+			setup: func(m mocks) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), key, gomock.Any()).Return(nil, idempotency.ErrKeyReused)
+			},
+			expectedBody: `{"errors":[{"message":"idempotency key was already used with a different request","path":["createPerson"],"extensions":{"code":"BAD_USER_INPUT"}}],"data":{"createPerson":null}}`,
+		},
+		{
+			name:           "failed mutation releases the key",
+			idempotencyKey: "k1",
+			setup: func(m mocks) {
+				m.idempotencyStore.EXPECT().Begin(gomock.Any(), key, gomock.Any()).Return(nil, nil)
+				m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).Return(entity.Person{}, errors.New("connection refused"))
+				m.idempotencyStore.EXPECT().Abort(gomock.Any(), key).Return(nil)
+			},
+			expectedBody: `{"errors":[{"message":"internal server error","path":["createPerson"],"extensions":{"code":"INTERNAL_SERVER_ERROR"}}],"data":{"createPerson":null}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+			if test.setup != nil {
+				test.setup(m)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(mutation))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", "application/json")
+			if test.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", test.idempotencyKey)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, "Test case %s failed: Status not as expected", test.name)
+			assert.JSONEq(t, test.expectedBody, w.Body.String(), "Test case %s failed: Body not as expected", test.name)
+		})
+	}
+}
+
+func TestHandler_IdempotentCanceledRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		key  string
+	}{
+		{
+			name: "rest",
+			path: "/api/v2/people",
+			body: personBody,
+			key:  "people:create:user-1:k1",
+		},
+		{
+			name: "graphql",
+			path: "/query",
+			body: `{"query":"mutation { createPerson(input: {name: \"Ivan\", surname: \"Ivanov\", age: 30, gender: \"male\", nationality: \"RU\"}) { id } }"}`,
+			key:  "graphql:createPerson:user-1:k1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The client goes away while the person is created, the response still has to be remembered
+			var completeErr error
+			m.idempotencyStore.EXPECT().Begin(gomock.Any(), test.key, gomock.Any()).Return(nil, nil)
+			m.peopleService.EXPECT().CreatePerson(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(context.Context, entity.Person) (entity.Person, error) {
+					cancel()
+					return createdPerson, nil
+				})
+			m.idempotencyStore.EXPECT().Complete(gomock.Any(), test.key, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, _ string, _ entity.IdempotentResponse) error {
+					completeErr = ctx.Err()
+					return nil
+				})
+
+			req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body)).WithContext(ctx)
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", "k1")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.NoError(t, completeErr, "Test case %s failed: Context of Complete not as expected", test.name)
+		})
+	}
+}
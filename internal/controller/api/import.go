@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	importFormatCSV  = "csv"
+	importFormatJSON = "json"
+
+	maxImportEnrichWorkers = 8
+)
+
+var (
+	ErrUnknownImportFormat = apperr.New(apperr.KindValidation, "unknown_import_format", "unknown import format, expected one of csv, json")
+	ErrInvalidImportMap    = apperr.New(apperr.KindValidation, "invalid_import_mapping", "invalid mapping, expected column:field pairs separated by commas")
+)
+
+// importFields are the person fields an uploaded column can be mapped onto.
+var importFields = map[string]bool{
+	"name":        true,
+	"surname":     true,
+	"patronymic":  true,
+	"age":         true,
+	"gender":      true,
+	"nationality": true,
+}
+
+// importRow is a single record of an uploaded file, err is set when the record could not be used.
+type importRow struct {
+	person entity.Person
+	err    error
+}
+
+// parseImportMapping reads the "column:field,column:field" mapping of the uploaded columns onto person fields.
+func parseImportMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if s == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		column, field, ok := strings.Cut(pair, ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || !importFields[field] {
+			return nil, ErrInvalidImportMap
+		}
+		mapping[strings.TrimSpace(column)] = field
+	}
+	return mapping, nil
+}
+
+// importField resolves the person field of a column, unmapped columns match the fields by name.
+func importField(column string, mapping map[string]string) (string, bool) {
+	if field, ok := mapping[column]; ok {
+		return field, true
+	}
+	field := strings.ToLower(strings.TrimSpace(column))
+	return field, importFields[field]
+}
+
+func readImportRows(r io.Reader, format string, mapping map[string]string) ([]importRow, error) {
+	switch format {
+	case importFormatCSV:
+		return readCSVImportRows(r, mapping)
+	case importFormatJSON:
+		return readJSONImportRows(r, mapping)
+	default:
+		return nil, ErrUnknownImportFormat
+	}
+}
+
+func readCSVImportRows(r io.Reader, mapping map[string]string) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, importRow{err: err})
+			continue
+		}
+
+		var row importRow
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+			field, ok := importField(header[i], mapping)
+			if !ok {
+				continue
+			}
+			if err := setPersonField(&row.person, field, strings.TrimSpace(value)); err != nil {
+				row.err = err
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func setPersonField(person *entity.Person, field, value string) error {
+	switch field {
+	case "name":
+		person.Name = value
+	case "surname":
+		person.Surname = value
+	case "patronymic":
+		person.Patronymic = value
+	case "age":
+		if value == "" {
+			return nil
+		}
+		age, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("field age must be an integer")
+		}
+		person.Age = age
+	case "gender":
+		person.Gender = value
+	case "nationality":
+		person.Nationality = value
+	}
+	return nil
+}
+
+func readJSONImportRows(r io.Reader, mapping map[string]string) ([]importRow, error) {
+	var records []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	rows := make([]importRow, 0, len(records))
+	for _, record := range records {
+		fields := make(map[string]json.RawMessage, len(record))
+		for key, value := range record {
+			if field, ok := importField(key, mapping); ok {
+				fields[field] = value
+			}
+		}
+
+		var row importRow
+		fieldsJSON, err := json.Marshal(fields)
+		if err == nil {
+			err = json.Unmarshal(fieldsJSON, &row.person)
+		}
+		if err != nil {
+			row.err = fmt.Errorf("invalid record: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// enrichImportRows completes the readable rows that miss age, gender or nationality
+// through the enrichment APIs, a failed request marks the row as invalid.
+func (h *Handler) enrichImportRows(ctx context.Context, rows []importRow) {
+	g := new(errgroup.Group)
+	g.SetLimit(maxImportEnrichWorkers)
+
+	for i := range rows {
+		row := &rows[i]
+		if row.err != nil {
+			continue
+		}
+		g.Go(func() error {
+			if err := h.personEnricher.EnrichPerson(ctx, &row.person); err != nil {
+				row.err = fmt.Errorf("failed to enrich person: %w", err)
+			}
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+}
+
+// newImportReport merges the rows that could not be read with the service results
+// of the remaining ones, so that every result carries the index of its row in the file.
+func newImportReport(rows []importRow, rowIndexes []int, result entity.BulkCreateResult, dryRun bool) entity.ImportReport {
+	report := entity.ImportReport{
+		DryRun: dryRun,
+		BulkCreateResult: entity.BulkCreateResult{
+			Created: result.Created,
+			Failed:  result.Failed,
+			Results: make([]entity.BulkItemResult, len(rows)),
+		},
+	}
+	for i, row := range rows {
+		if row.err != nil {
+			report.Results[i] = entity.BulkItemResult{Index: i, Status: entity.BulkStatusInvalid, Error: row.err.Error()}
+			report.Failed++
+		}
+	}
+	for _, itemResult := range result.Results {
+		rowIndex := rowIndexes[itemResult.Index]
+		itemResult.Index = rowIndex
+		report.Results[rowIndex] = itemResult
+	}
+	return report
+}
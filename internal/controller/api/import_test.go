@@ -0,0 +1,265 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validatePeople stands in for the validation of the service: a person needs a name and an age.
+func validatePeople(people []entity.Person) entity.BulkCreateResult {
+	result := entity.BulkCreateResult{Results: make([]entity.BulkItemResult, len(people))}
+	for i, person := range people {
+		result.Results[i] = entity.BulkItemResult{Index: i, Status: entity.BulkStatusValid}
+		if person.Name == "" || person.Age == 0 {
+			result.Results[i] = entity.BulkItemResult{Index: i, Status: entity.BulkStatusInvalid, Error: "field is required"}
+			result.Failed++
+		}
+	}
+	return result
+}
+
+func createPeople(_ context.Context, people []entity.Person) (entity.BulkCreateResult, error) {
+	result := validatePeople(people)
+	for i := range result.Results {
+		if result.Results[i].Status == entity.BulkStatusValid {
+			result.Results[i].Status = entity.BulkStatusCreated
+			result.Created++
+		}
+	}
+	return result, nil
+}
+
+func TestHandler_ImportPeople(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name             string
+		fixture          string
+		filename         string
+		query            string
+		setup            func(m mocks)
+		expectedPeople   []entity.Person
+		expectedStatus   int
+		expectedStatuses []string
+		expectedErrors   map[int]string
+		expectedCreated  int
+		expectedFailed   int
+		expectedBody     string
+	}{
+		{
+			name:    "csv rows",
+			fixture: "people.csv",
+			query:   "dryRun=true",
+			expectedPeople: []entity.Person{
+				{Name: "Ivan", Surname: "Ivanov", Patronymic: "Sergeevich", Age: 30, Gender: "male", Nationality: "RU"},
+				{Surname: "Smirnov", Age: 25, Gender: "male", Nationality: "RU"},
+				{Name: "Olga", Surname: "Kuznetsova", Age: 28, Gender: "female", Nationality: "KZ"},
+				{Name: "Dmitry", Surname: "Popov"},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{"valid", "invalid", "invalid", "invalid", "valid", "invalid"},
+			expectedErrors: map[int]string{
+				1: "field age must be an integer",
+				2: "parse error on line 4",
+				3: "field is required",
+				5: "field is required",
+			},
+			expectedFailed: 4,
+		},
+		{
+			name:    "csv columns mapped onto fields",
+			fixture: "mapped.csv",
+			query:   "dryRun=true&mapping=Имя:name,Фамилия:surname,Возраст:age",
+			expectedPeople: []entity.Person{
+				{Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male"},
+				{Name: "Anna", Surname: "Petrova", Gender: "female"},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{"valid", "invalid"},
+			expectedFailed:   1,
+		},
+		{
+			name:    "json records",
+			fixture: "people.json",
+			query:   "dryRun=true&mapping=Имя:name",
+			expectedPeople: []entity.Person{
+				{Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU"},
+				{Name: "Petr", Surname: "Sidorov"},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{"valid", "invalid", "invalid", "invalid"},
+			expectedErrors: map[int]string{
+				1: "invalid record",
+				2: "field is required",
+				3: "invalid record",
+			},
+			expectedFailed: 3,
+		},
+		{
+			name:     "format overrides the extension",
+			fixture:  "people.json",
+			filename: "people.txt",
+			query:    "format=json&mapping=Имя:name",
+			expectedPeople: []entity.Person{
+				{Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU"},
+				{Name: "Petr", Surname: "Sidorov"},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{"created", "invalid", "invalid", "invalid"},
+			expectedCreated:  1,
+			expectedFailed:   3,
+		},
+		{
+			name:    "failed enrichment marks the row",
+			fixture: "people.csv",
+			query:   "dryRun=true&enrich=true",
+			setup: func(m mocks) {
+				m.personEnricher.EXPECT().EnrichPerson(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, person *entity.Person) error {
+						if person.Surname == "Popov" {
+							return errors.New("agify.io is unavailable")
+						}
+						return nil
+					}).Times(4)
+			},
+			expectedPeople: []entity.Person{
+				{Name: "Ivan", Surname: "Ivanov", Patronymic: "Sergeevich", Age: 30, Gender: "male", Nationality: "RU"},
+				{Surname: "Smirnov", Age: 25, Gender: "male", Nationality: "RU"},
+				{Name: "Olga", Surname: "Kuznetsova", Age: 28, Gender: "female", Nationality: "KZ"},
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{"valid", "invalid", "invalid", "invalid", "valid", "invalid"},
+			expectedErrors:   map[int]string{5: "failed to enrich person: agify.io is unavailable"},
+			expectedFailed:   4,
+		},
+		{
+			name:           "csv without a header",
+			fixture:        "empty.csv",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "failed to read csv header",
+		},
+		{
+			name:           "json that is not an array",
+			fixture:        "malformed.json",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "failed to decode json",
+		},
+		{
+			name:           "unknown format",
+			fixture:        "people.csv",
+			filename:       "people.txt",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unknown_import_format",
+		},
+		{
+			name:           "mapping without a field",
+			fixture:        "mapped.csv",
+			query:          "mapping=Имя",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid_import_mapping",
+		},
+		{
+			name:           "mapping onto an unknown field",
+			fixture:        "mapped.csv",
+			query:          "mapping=Имя:id",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid_import_mapping",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+			if test.setup != nil {
+				test.setup(m)
+			}
+			if test.expectedPeople != nil {
+				if test.expectedCreated > 0 {
+					m.peopleService.EXPECT().CreatePeople(gomock.Any(), test.expectedPeople).DoAndReturn(createPeople)
+				} else {
+					m.peopleService.EXPECT().ValidatePeople(test.expectedPeople).DoAndReturn(validatePeople)
+				}
+			}
+			filename := test.filename
+			if filename == "" {
+				filename = test.fixture
+			}
+
+			req := newImportRequest(t, "/api/people/import?"+test.query, filepath.Join("testdata", "import", test.fixture), filename)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+			if test.expectedStatus != http.StatusOK {
+				assert.Contains(t, w.Body.String(), test.expectedBody, "Test case %s failed: Body not as expected", test.name)
+				return
+			}
+
+			var report entity.ImportReport
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			statuses := make([]string, len(report.Results))
+			for i, result := range report.Results {
+				assert.Equal(t, i, result.Index, "Test case %s failed: Index not as expected", test.name)
+				statuses[i] = result.Status
+			}
+			assert.Equal(t, test.expectedStatuses, statuses, "Test case %s failed: Statuses not as expected", test.name)
+			for i, expectedErr := range test.expectedErrors {
+				assert.Contains(t, report.Results[i].Error, expectedErr, "Test case %s failed: Error of row %d not as expected", test.name, i)
+			}
+			assert.Equal(t, test.expectedCreated, report.Created, "Test case %s failed: Created not as expected", test.name)
+			assert.Equal(t, test.expectedFailed, report.Failed, "Test case %s failed: Failed not as expected", test.name)
+			assert.Equal(t, strings.Contains(test.query, "dryRun=true"), report.DryRun, "Test case %s failed: DryRun not as expected", test.name)
+		})
+	}
+}
+
+func TestHandler_ImportPeopleWithoutFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, m := newTestHandler(ctrl, config.HTTPConfig{})
+	m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/people/import", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func newImportRequest(t *testing.T, target, fixture, filename string) *http.Request {
+	t.Helper()
+	data, err := os.ReadFile(fixture)
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
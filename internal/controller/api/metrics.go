@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// unmatchedRoute labels the requests that matched no route, their paths would flood the metrics.
+const unmatchedRoute = "unmatched"
+
+// observe records the count and the latency of the requests per route.
+func (h *Handler) observe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		h.metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// observeGraphQL records the count, the errors and the latency of the GraphQL operations by their name.
+func (h *Handler) observeGraphQL(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return nil
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	operation := "anonymous"
+	if oc.Operation != nil && oc.Operation.Name != "" {
+		operation = oc.Operation.Name
+	}
+	h.metrics.ObserveGraphQLOperation(operation, len(resp.Errors) > 0, time.Since(oc.Stats.OperationStart))
+
+	return resp
+}
@@ -0,0 +1,726 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: router.go
+
+// Package api is a generated GoMock package.
+package api
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	auth "github.com/khasmag06/effective-mobile-test/internal/auth"
+	entity "github.com/khasmag06/effective-mobile-test/internal/entity"
+	health "github.com/khasmag06/effective-mobile-test/pkg/health"
+	ratelimit "github.com/khasmag06/effective-mobile-test/pkg/ratelimit"
+)
+
+// MockpeopleService is a mock of peopleService interface.
+type MockpeopleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockpeopleServiceMockRecorder
+}
+
+// MockpeopleServiceMockRecorder is the mock recorder for MockpeopleService.
+type MockpeopleServiceMockRecorder struct {
+	mock *MockpeopleService
+}
+
+// NewMockpeopleService creates a new mock instance.
+func NewMockpeopleService(ctrl *gomock.Controller) *MockpeopleService {
+	mock := &MockpeopleService{ctrl: ctrl}
+	mock.recorder = &MockpeopleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpeopleService) EXPECT() *MockpeopleServiceMockRecorder {
+	return m.recorder
+}
+
+// CreatePeople mocks base method.
+func (m *MockpeopleService) CreatePeople(ctx context.Context, people []entity.Person) (entity.BulkCreateResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePeople", ctx, people)
+	ret0, _ := ret[0].(entity.BulkCreateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePeople indicates an expected call of CreatePeople.
+func (mr *MockpeopleServiceMockRecorder) CreatePeople(ctx, people interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePeople", reflect.TypeOf((*MockpeopleService)(nil).CreatePeople), ctx, people)
+}
+
+// CreatePerson mocks base method.
+func (m *MockpeopleService) CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePerson", ctx, person)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePerson indicates an expected call of CreatePerson.
+func (mr *MockpeopleServiceMockRecorder) CreatePerson(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePerson", reflect.TypeOf((*MockpeopleService)(nil).CreatePerson), ctx, person)
+}
+
+// DeletePersonData mocks base method.
+func (m *MockpeopleService) DeletePersonData(ctx context.Context, personID, expectedVersion int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePersonData", ctx, personID, expectedVersion)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePersonData indicates an expected call of DeletePersonData.
+func (mr *MockpeopleServiceMockRecorder) DeletePersonData(ctx, personID, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePersonData", reflect.TypeOf((*MockpeopleService)(nil).DeletePersonData), ctx, personID, expectedVersion)
+}
+
+// ExportPeople mocks base method.
+func (m *MockpeopleService) ExportPeople(ctx context.Context, sortBy, sortOrder string, filter entity.PeopleFilter, fn func(entity.Person) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportPeople", ctx, sortBy, sortOrder, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportPeople indicates an expected call of ExportPeople.
+func (mr *MockpeopleServiceMockRecorder) ExportPeople(ctx, sortBy, sortOrder, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportPeople", reflect.TypeOf((*MockpeopleService)(nil).ExportPeople), ctx, sortBy, sortOrder, filter, fn)
+}
+
+// GetDeletedPeople mocks base method.
+func (m *MockpeopleService) GetDeletedPeople(ctx context.Context, page, limit int) ([]entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedPeople", ctx, page, limit)
+	ret0, _ := ret[0].([]entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedPeople indicates an expected call of GetDeletedPeople.
+func (mr *MockpeopleServiceMockRecorder) GetDeletedPeople(ctx, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedPeople", reflect.TypeOf((*MockpeopleService)(nil).GetDeletedPeople), ctx, page, limit)
+}
+
+// GetPeople mocks base method.
+func (m *MockpeopleService) GetPeople(ctx context.Context, page, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeople", ctx, page, limit, sortBy, sortOrder, filter)
+	ret0, _ := ret[0].([]entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeople indicates an expected call of GetPeople.
+func (mr *MockpeopleServiceMockRecorder) GetPeople(ctx, page, limit, sortBy, sortOrder, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeople", reflect.TypeOf((*MockpeopleService)(nil).GetPeople), ctx, page, limit, sortBy, sortOrder, filter)
+}
+
+// GetPeopleByCursor mocks base method.
+func (m *MockpeopleService) GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeopleByCursor", ctx, cursor, limit, sortBy, sortOrder, filter)
+	ret0, _ := ret[0].(entity.PeopleCursorPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeopleByCursor indicates an expected call of GetPeopleByCursor.
+func (mr *MockpeopleServiceMockRecorder) GetPeopleByCursor(ctx, cursor, limit, sortBy, sortOrder, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeopleByCursor", reflect.TypeOf((*MockpeopleService)(nil).GetPeopleByCursor), ctx, cursor, limit, sortBy, sortOrder, filter)
+}
+
+// GetPeoplePage mocks base method.
+func (m *MockpeopleService) GetPeoplePage(ctx context.Context, page, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeoplePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeoplePage", ctx, page, limit, sortBy, sortOrder, filter)
+	ret0, _ := ret[0].(entity.PeoplePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeoplePage indicates an expected call of GetPeoplePage.
+func (mr *MockpeopleServiceMockRecorder) GetPeoplePage(ctx, page, limit, sortBy, sortOrder, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeoplePage", reflect.TypeOf((*MockpeopleService)(nil).GetPeoplePage), ctx, page, limit, sortBy, sortOrder, filter)
+}
+
+// GetPeopleStats mocks base method.
+func (m *MockpeopleService) GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeopleStats", ctx, query)
+	ret0, _ := ret[0].(entity.PeopleStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeopleStats indicates an expected call of GetPeopleStats.
+func (mr *MockpeopleServiceMockRecorder) GetPeopleStats(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeopleStats", reflect.TypeOf((*MockpeopleService)(nil).GetPeopleStats), ctx, query)
+}
+
+// GetPersonByID mocks base method.
+func (m *MockpeopleService) GetPersonByID(ctx context.Context, personID int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonByID", ctx, personID)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonByID indicates an expected call of GetPersonByID.
+func (mr *MockpeopleServiceMockRecorder) GetPersonByID(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonByID", reflect.TypeOf((*MockpeopleService)(nil).GetPersonByID), ctx, personID)
+}
+
+// GetPersonHistory mocks base method.
+func (m *MockpeopleService) GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonHistory", ctx, personID)
+	ret0, _ := ret[0].([]entity.PersonRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonHistory indicates an expected call of GetPersonHistory.
+func (mr *MockpeopleServiceMockRecorder) GetPersonHistory(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonHistory", reflect.TypeOf((*MockpeopleService)(nil).GetPersonHistory), ctx, personID)
+}
+
+// PatchPerson mocks base method.
+func (m *MockpeopleService) PatchPerson(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchPerson", ctx, personID, patch, expectedVersion)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchPerson indicates an expected call of PatchPerson.
+func (mr *MockpeopleServiceMockRecorder) PatchPerson(ctx, personID, patch, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPerson", reflect.TypeOf((*MockpeopleService)(nil).PatchPerson), ctx, personID, patch, expectedVersion)
+}
+
+// RestorePerson mocks base method.
+func (m *MockpeopleService) RestorePerson(ctx context.Context, personID int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePerson", ctx, personID)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestorePerson indicates an expected call of RestorePerson.
+func (mr *MockpeopleServiceMockRecorder) RestorePerson(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePerson", reflect.TypeOf((*MockpeopleService)(nil).RestorePerson), ctx, personID)
+}
+
+// RevertPerson mocks base method.
+func (m *MockpeopleService) RevertPerson(ctx context.Context, personID, revision int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertPerson", ctx, personID, revision)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertPerson indicates an expected call of RevertPerson.
+func (mr *MockpeopleServiceMockRecorder) RevertPerson(ctx, personID, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertPerson", reflect.TypeOf((*MockpeopleService)(nil).RevertPerson), ctx, personID, revision)
+}
+
+// SearchPeople mocks base method.
+func (m *MockpeopleService) SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPeople", ctx, query, limit)
+	ret0, _ := ret[0].([]entity.PersonSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPeople indicates an expected call of SearchPeople.
+func (mr *MockpeopleServiceMockRecorder) SearchPeople(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPeople", reflect.TypeOf((*MockpeopleService)(nil).SearchPeople), ctx, query, limit)
+}
+
+// UpdatePersonData mocks base method.
+func (m *MockpeopleService) UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePersonData", ctx, personID, person, expectedVersion)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePersonData indicates an expected call of UpdatePersonData.
+func (mr *MockpeopleServiceMockRecorder) UpdatePersonData(ctx, personID, person, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePersonData", reflect.TypeOf((*MockpeopleService)(nil).UpdatePersonData), ctx, personID, person, expectedVersion)
+}
+
+// ValidatePeople mocks base method.
+func (m *MockpeopleService) ValidatePeople(people []entity.Person) entity.BulkCreateResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidatePeople", people)
+	ret0, _ := ret[0].(entity.BulkCreateResult)
+	return ret0
+}
+
+// ValidatePeople indicates an expected call of ValidatePeople.
+func (mr *MockpeopleServiceMockRecorder) ValidatePeople(people interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidatePeople", reflect.TypeOf((*MockpeopleService)(nil).ValidatePeople), people)
+}
+
+// MockidempotencyStore is a mock of idempotencyStore interface.
+type MockidempotencyStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockidempotencyStoreMockRecorder
+}
+
+// MockidempotencyStoreMockRecorder is the mock recorder for MockidempotencyStore.
+type MockidempotencyStoreMockRecorder struct {
+	mock *MockidempotencyStore
+}
+
+// NewMockidempotencyStore creates a new mock instance.
+func NewMockidempotencyStore(ctrl *gomock.Controller) *MockidempotencyStore {
+	mock := &MockidempotencyStore{ctrl: ctrl}
+	mock.recorder = &MockidempotencyStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockidempotencyStore) EXPECT() *MockidempotencyStoreMockRecorder {
+	return m.recorder
+}
+
+// Abort mocks base method.
+func (m *MockidempotencyStore) Abort(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Abort", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Abort indicates an expected call of Abort.
+func (mr *MockidempotencyStoreMockRecorder) Abort(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Abort", reflect.TypeOf((*MockidempotencyStore)(nil).Abort), ctx, key)
+}
+
+// Begin mocks base method.
+func (m *MockidempotencyStore) Begin(ctx context.Context, key, requestHash string) (*entity.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx, key, requestHash)
+	ret0, _ := ret[0].(*entity.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin.
+func (mr *MockidempotencyStoreMockRecorder) Begin(ctx, key, requestHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockidempotencyStore)(nil).Begin), ctx, key, requestHash)
+}
+
+// Complete mocks base method.
+func (m *MockidempotencyStore) Complete(ctx context.Context, key string, response entity.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockidempotencyStoreMockRecorder) Complete(ctx, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockidempotencyStore)(nil).Complete), ctx, key, response)
+}
+
+// MockapiKeyService is a mock of apiKeyService interface.
+type MockapiKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockapiKeyServiceMockRecorder
+}
+
+// MockapiKeyServiceMockRecorder is the mock recorder for MockapiKeyService.
+type MockapiKeyServiceMockRecorder struct {
+	mock *MockapiKeyService
+}
+
+// NewMockapiKeyService creates a new mock instance.
+func NewMockapiKeyService(ctrl *gomock.Controller) *MockapiKeyService {
+	mock := &MockapiKeyService{ctrl: ctrl}
+	mock.recorder = &MockapiKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockapiKeyService) EXPECT() *MockapiKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateAPIKey mocks base method.
+func (m *MockapiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (auth.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", ctx, key)
+	ret0, _ := ret[0].(auth.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey.
+func (mr *MockapiKeyServiceMockRecorder) AuthenticateAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockapiKeyService)(nil).AuthenticateAPIKey), ctx, key)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockapiKeyService) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockapiKeyServiceMockRecorder) GetAPIKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockapiKeyService)(nil).GetAPIKeys), ctx)
+}
+
+// IssueAPIKey mocks base method.
+func (m *MockapiKeyService) IssueAPIKey(ctx context.Context, req entity.APIKeyRequest) (entity.IssuedAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAPIKey", ctx, req)
+	ret0, _ := ret[0].(entity.IssuedAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAPIKey indicates an expected call of IssueAPIKey.
+func (mr *MockapiKeyServiceMockRecorder) IssueAPIKey(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAPIKey", reflect.TypeOf((*MockapiKeyService)(nil).IssueAPIKey), ctx, req)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockapiKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockapiKeyServiceMockRecorder) RevokeAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockapiKeyService)(nil).RevokeAPIKey), ctx, id)
+}
+
+// MocktokenVerifier is a mock of tokenVerifier interface.
+type MocktokenVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MocktokenVerifierMockRecorder
+}
+
+// MocktokenVerifierMockRecorder is the mock recorder for MocktokenVerifier.
+type MocktokenVerifierMockRecorder struct {
+	mock *MocktokenVerifier
+}
+
+// NewMocktokenVerifier creates a new mock instance.
+func NewMocktokenVerifier(ctrl *gomock.Controller) *MocktokenVerifier {
+	mock := &MocktokenVerifier{ctrl: ctrl}
+	mock.recorder = &MocktokenVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktokenVerifier) EXPECT() *MocktokenVerifierMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method.
+func (m *MocktokenVerifier) Verify(token string) (auth.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", token)
+	ret0, _ := ret[0].(auth.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MocktokenVerifierMockRecorder) Verify(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MocktokenVerifier)(nil).Verify), token)
+}
+
+// MockrateLimiter is a mock of rateLimiter interface.
+type MockrateLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockrateLimiterMockRecorder
+}
+
+// MockrateLimiterMockRecorder is the mock recorder for MockrateLimiter.
+type MockrateLimiterMockRecorder struct {
+	mock *MockrateLimiter
+}
+
+// NewMockrateLimiter creates a new mock instance.
+func NewMockrateLimiter(ctrl *gomock.Controller) *MockrateLimiter {
+	mock := &MockrateLimiter{ctrl: ctrl}
+	mock.recorder = &MockrateLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrateLimiter) EXPECT() *MockrateLimiterMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockrateLimiter) Allow(ctx context.Context, key string, limit ratelimit.Limit, cost int) (ratelimit.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", ctx, key, limit, cost)
+	ret0, _ := ret[0].(ratelimit.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockrateLimiterMockRecorder) Allow(ctx, key, limit, cost interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockrateLimiter)(nil).Allow), ctx, key, limit, cost)
+}
+
+// MockpersonEnricher is a mock of personEnricher interface.
+type MockpersonEnricher struct {
+	ctrl     *gomock.Controller
+	recorder *MockpersonEnricherMockRecorder
+}
+
+// MockpersonEnricherMockRecorder is the mock recorder for MockpersonEnricher.
+type MockpersonEnricherMockRecorder struct {
+	mock *MockpersonEnricher
+}
+
+// NewMockpersonEnricher creates a new mock instance.
+func NewMockpersonEnricher(ctrl *gomock.Controller) *MockpersonEnricher {
+	mock := &MockpersonEnricher{ctrl: ctrl}
+	mock.recorder = &MockpersonEnricherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpersonEnricher) EXPECT() *MockpersonEnricherMockRecorder {
+	return m.recorder
+}
+
+// EnrichPerson mocks base method.
+func (m *MockpersonEnricher) EnrichPerson(ctx context.Context, person *entity.Person) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrichPerson", ctx, person)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnrichPerson indicates an expected call of EnrichPerson.
+func (mr *MockpersonEnricherMockRecorder) EnrichPerson(ctx, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrichPerson", reflect.TypeOf((*MockpersonEnricher)(nil).EnrichPerson), ctx, person)
+}
+
+// MockhealthChecker is a mock of healthChecker interface.
+type MockhealthChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockhealthCheckerMockRecorder
+}
+
+// MockhealthCheckerMockRecorder is the mock recorder for MockhealthChecker.
+type MockhealthCheckerMockRecorder struct {
+	mock *MockhealthChecker
+}
+
+// NewMockhealthChecker creates a new mock instance.
+func NewMockhealthChecker(ctrl *gomock.Controller) *MockhealthChecker {
+	mock := &MockhealthChecker{ctrl: ctrl}
+	mock.recorder = &MockhealthCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockhealthChecker) EXPECT() *MockhealthCheckerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockhealthChecker) Check(ctx context.Context) health.Report {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx)
+	ret0, _ := ret[0].(health.Report)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockhealthCheckerMockRecorder) Check(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockhealthChecker)(nil).Check), ctx)
+}
+
+// Mockmetrics is a mock of metrics interface.
+type Mockmetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockmetricsMockRecorder
+}
+
+// MockmetricsMockRecorder is the mock recorder for Mockmetrics.
+type MockmetricsMockRecorder struct {
+	mock *Mockmetrics
+}
+
+// NewMockmetrics creates a new mock instance.
+func NewMockmetrics(ctrl *gomock.Controller) *Mockmetrics {
+	mock := &Mockmetrics{ctrl: ctrl}
+	mock.recorder = &MockmetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockmetrics) EXPECT() *MockmetricsMockRecorder {
+	return m.recorder
+}
+
+// Handler mocks base method.
+func (m *Mockmetrics) Handler() http.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handler")
+	ret0, _ := ret[0].(http.Handler)
+	return ret0
+}
+
+// Handler indicates an expected call of Handler.
+func (mr *MockmetricsMockRecorder) Handler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handler", reflect.TypeOf((*Mockmetrics)(nil).Handler))
+}
+
+// ObserveGraphQLOperation mocks base method.
+func (m *Mockmetrics) ObserveGraphQLOperation(operation string, failed bool, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveGraphQLOperation", operation, failed, duration)
+}
+
+// ObserveGraphQLOperation indicates an expected call of ObserveGraphQLOperation.
+func (mr *MockmetricsMockRecorder) ObserveGraphQLOperation(operation, failed, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveGraphQLOperation", reflect.TypeOf((*Mockmetrics)(nil).ObserveGraphQLOperation), operation, failed, duration)
+}
+
+// ObserveHTTPRequest mocks base method.
+func (m *Mockmetrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ObserveHTTPRequest", method, route, status, duration)
+}
+
+// ObserveHTTPRequest indicates an expected call of ObserveHTTPRequest.
+func (mr *MockmetricsMockRecorder) ObserveHTTPRequest(method, route, status, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObserveHTTPRequest", reflect.TypeOf((*Mockmetrics)(nil).ObserveHTTPRequest), method, route, status, duration)
+}
+
+// Mocklogger is a mock of logger interface.
+type Mocklogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockloggerMockRecorder
+}
+
+// MockloggerMockRecorder is the mock recorder for Mocklogger.
+type MockloggerMockRecorder struct {
+	mock *Mocklogger
+}
+
+// NewMocklogger creates a new mock instance.
+func NewMocklogger(ctrl *gomock.Controller) *Mocklogger {
+	mock := &Mocklogger{ctrl: ctrl}
+	mock.recorder = &MockloggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mocklogger) EXPECT() *MockloggerMockRecorder {
+	return m.recorder
+}
+
+// ErrorContext mocks base method.
+func (m *Mocklogger) ErrorContext(ctx context.Context, text ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range text {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "ErrorContext", varargs...)
+}
+
+// ErrorContext indicates an expected call of ErrorContext.
+func (mr *MockloggerMockRecorder) ErrorContext(ctx interface{}, text ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, text...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ErrorContext", reflect.TypeOf((*Mocklogger)(nil).ErrorContext), varargs...)
+}
+
+// ErrorfContext mocks base method.
+func (m *Mocklogger) ErrorfContext(ctx context.Context, format string, args ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, format}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "ErrorfContext", varargs...)
+}
+
+// ErrorfContext indicates an expected call of ErrorfContext.
+func (mr *MockloggerMockRecorder) ErrorfContext(ctx, format interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, format}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ErrorfContext", reflect.TypeOf((*Mocklogger)(nil).ErrorfContext), varargs...)
+}
+
+// InfoContext mocks base method.
+func (m *Mocklogger) InfoContext(ctx context.Context, text ...any) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range text {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InfoContext", varargs...)
+}
+
+// InfoContext indicates an expected call of InfoContext.
+func (mr *MockloggerMockRecorder) InfoContext(ctx interface{}, text ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, text...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InfoContext", reflect.TypeOf((*Mocklogger)(nil).InfoContext), varargs...)
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+// @Tags People v2
+// @Summary list people
+// @Description get a page of people with pagination, sorting and filtering, the total count and links to the neighbouring pages.
+// @Description Passing the cursor parameter (empty for the first page) switches to keyset pagination,
+// @Description the response is then an object with the people and the next/previous page cursors.
+// @ID listPeopleV2
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param page query int false "Page number (default is 1)"
+// @Param cursor query string false "Opaque page cursor, enables cursor pagination"
+// @Param limit query int false "Number of items per page (default is 10)"
+// @Param sortBy query string false "Sorting field (default is 'date')"
+// @Param sortOrder query string false "Sorting order (default is 'asc')"
+// @Param name query string false "Name prefix"
+// @Param surname query string false "Surname prefix"
+// @Param patronymic query string false "Patronymic prefix"
+// @Param ageFrom query int false "Minimum age"
+// @Param ageTo query int false "Maximum age"
+// @Param gender query string false "Gender (male or female)"
+// @Param nationality query []string false "Nationalities" collectionFormat(multi)
+// @Param createdFrom query string false "Created at or after (RFC 3339)"
+// @Param createdTo query string false "Created at or before (RFC 3339)"
+// @Success 200 {object} peoplePageResponse "Page of people"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /v2/people [get]
+func (h *Handler) listPeopleV2(c *gin.Context) {
+	query, ok := h.bindPeopleQuery(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	cursor, ok := c.GetQuery("cursor")
+	if !ok {
+		peoplePage, err := h.peopleService.GetPeoplePage(ctx, query.page, query.limit, query.sortBy, query.sortOrder, query.filter)
+		if err != nil {
+			h.logger.ErrorfContext(ctx, "failed to fetch people data: %v", err.Error())
+			writeServerError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newPeoplePageResponse(c.Request.URL, peoplePage))
+		return
+	}
+
+	peoplePage, err := h.peopleService.GetPeopleByCursor(ctx, cursor, query.limit, query.sortBy, query.sortOrder, query.filter)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrInvalidCursor) {
+			h.logger.ErrorfContext(ctx, "error when receiving people page: %v", err.Error())
+			writeProblem(c, http.StatusBadRequest, err)
+			return
+		}
+		h.logger.ErrorfContext(ctx, "failed to fetch people data: %v", err.Error())
+		writeServerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, peoplePage)
+}
+
+// @Tags People v2
+// @Summary create a person
+// @Description create a new person and return it as stored, with its id and version
+// @ID createPersonV2
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param Idempotency-Key header string false "Repeating the key returns the original response instead of creating the person again"
+// @Param input body entity.Person true "person info"
+// @Success 201 {object} entity.Person
+// @Header 201 {string} Location "URL of the created person"
+// @Header 201 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 409 {object} problemDetails
+// @Failure 422 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /v2/people [post]
+func (h *Handler) createPersonV2(c *gin.Context) {
+	person, ok := h.createPerson(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Location", path.Join(c.Request.URL.Path, strconv.Itoa(person.ID)))
+	c.Header("ETag", etag(person.Version))
+	c.JSON(http.StatusCreated, person)
+}
+
+// @Tags People v2
+// @Summary get a person
+// @Description get a person by id
+// @ID getPersonV2
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int64 true "ID of the person to get"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /v2/people/{id} [get]
+func (h *Handler) getPersonV2(c *gin.Context) {
+	h.getPerson(c)
+}
+
+// @Tags People v2
+// @Summary replace a person
+// @Description overwrite a person and return it as stored
+// @ID replacePersonV2
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce json
+// @Param id path int64 true "ID of the person to replace"
+// @Param If-Match header string false "ETag of the person version the update is based on"
+// @Param input body entity.Person true "person info, a non-zero version works like If-Match"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 409 {object} problemDetails
+// @Failure 412 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /v2/people/{id} [put]
+func (h *Handler) replacePersonV2(c *gin.Context) {
+	person, ok := h.replacePerson(c)
+	if !ok {
+		return
+	}
+
+	c.Header("ETag", etag(person.Version))
+	c.JSON(http.StatusOK, person)
+}
+
+// @Tags People v2
+// @Summary patch a person
+// @Description partially update a person with a JSON Merge Patch (RFC 7396).
+// @Description Omitted fields are left unchanged, fields set to null are cleared.
+// @ID patchPersonV2
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Accept  application/merge-patch+json
+// @Produce json
+// @Param id path int64 true "ID of the person to patch"
+// @Param If-Match header string false "ETag of the person version the patch is based on"
+// @Param input body entity.PersonPatch true "fields to change"
+// @Success 200 {object} entity.Person
+// @Header 200 {string} ETag "person version"
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 412 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /v2/people/{id} [patch]
+func (h *Handler) patchPersonV2(c *gin.Context) {
+	h.patchPerson(c)
+}
+
+// @Tags People v2
+// @Summary delete a person
+// @Description move a person to the trash, it can be restored until the retention period passes
+// @ID deletePersonV2
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int64 true "ID of the person to delete"
+// @Param If-Match header string false "ETag of the person version the deletion is based on"
+// @Success 204
+// @Failure 400 {object} problemDetails
+// @Failure 401 {object} problemDetails
+// @Failure 403 {object} problemDetails
+// @Failure 429 {object} problemDetails
+// @Failure 404 {object} problemDetails
+// @Failure 412 {object} problemDetails
+// @Failure 500 {object} problemDetails
+// @Failure 503 {object} problemDetails
+// @Router /v2/people/{id} [delete]
+func (h *Handler) deletePersonV2(c *gin.Context) {
+	if !h.removePerson(c) {
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_ListPeopleV2(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name           string
+		target         string
+		setup          func(m mocks)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "page with links",
+			target: "/api/v2/people?page=2&limit=1",
+			setup: func(m mocks) {
+				m.peopleService.EXPECT().GetPeoplePage(gomock.Any(), 2, 1, "date", "asc", entity.PeopleFilter{}).
+					Return(entity.PeoplePage{Items: []entity.Person{}, Total: 3, Page: 2, Limit: 1, TotalPages: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"items":[],"total":3,"page":2,"limit":1,"totalPages":3,
+				"links":{"next":"/api/v2/people?limit=1&page=3","prev":"/api/v2/people?limit=1&page=1"}}`,
+		},
+		{
+			name:   "cursor page",
+			target: "/api/v2/people?cursor=&limit=1",
+			setup: func(m mocks) {
+				m.peopleService.EXPECT().GetPeopleByCursor(gomock.Any(), "", 1, "date", "asc", entity.PeopleFilter{}).
+					Return(entity.PeopleCursorPage{People: []entity.Person{}, NextCursor: "next"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"people":[],"nextCursor":"next"}`,
+		},
+		{
+			name:           "interim route is gone",
+			target:         "/api/v2/people/get",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleReader}}, nil)
+			if test.setup != nil {
+				test.setup(m)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+			assert.Empty(t, w.Header().Get("Deprecation"), "Test case %s failed: Deprecation not as expected", test.name)
+			if test.expectedBody != "" {
+				assert.JSONEq(t, test.expectedBody, w.Body.String(), "Test case %s failed: Body not as expected", test.name)
+			}
+		})
+	}
+}
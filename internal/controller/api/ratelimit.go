@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/pkg/ratelimit"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRateLimited     = apperr.New(apperr.KindRateLimited, "rate_limited", "too many requests, retry later")
+	ErrRequestTooLarge = apperr.New(apperr.KindValidation, "request_too_large", "request body is too large")
+)
+
+// maxGraphQLBodySize bounds the GraphQL request body, which is read whole to count its cost.
+const maxGraphQLBodySize = 1 << 20 // 1 MiB
+
+// rateLimit takes tokens from the bucket of the caller in the route group for every request, cost
+// decides how many. Callers are told apart by their identity, the anonymous ones by their IP.
+// The limit is not enforced while the limiter itself fails.
+func (h *Handler) rateLimit(group string, limit ratelimit.Limit, cost func(c *gin.Context) int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		clientKey := "ip:" + c.ClientIP()
+		if identity, ok := auth.IdentityFromContext(c.Request.Context()); ok {
+			clientKey = "sub:" + identity.Subject
+		}
+
+		result, err := h.rateLimiter.Allow(c.Request.Context(), group+":"+clientKey, limit, cost(c))
+		if err != nil {
+			h.logger.ErrorfContext(c.Request.Context(), "failed to check rate limit: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+		if !result.Allowed {
+			h.logger.ErrorfContext(c.Request.Context(), "rate limit of %s exceeded by %s", group, clientKey)
+			c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+			writeProblem(c, http.StatusTooManyRequests, ErrRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+func requestCost(*gin.Context) int {
+	return 1
+}
+
+// limitBody reads the request body up front and rejects it with 413 when it is larger than limit,
+// so that the handlers after it never buffer a body of any size.
+func limitBody(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeProblem(c, http.StatusRequestEntityTooLarge, ErrRequestTooLarge)
+			} else {
+				writeErrorResponse(c, http.StatusBadRequest, "invalid request body format")
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// graphQLCost counts the root fields of the requested operation, each of them is a separate
+// read or change of people. Requests that cannot be parsed cost one token, GraphQL rejects them anyway.
+// The body is expected to be bounded by limitBody.
+func graphQLCost(c *gin.Context) int {
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return 1
+	}
+
+	var params struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return 1
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: params.Query})
+	if err != nil {
+		return 1
+	}
+	operation := doc.Operations.ForName(params.OperationName)
+	if operation == nil {
+		return 1
+	}
+
+	return max(countFields(operation.SelectionSet, doc.Fragments, 0), 1)
+}
+
+// maxFragmentDepth bounds the expansion of fragments that spread each other.
+const maxFragmentDepth = 10
+
+func countFields(selections ast.SelectionSet, fragments ast.FragmentDefinitionList, depth int) int {
+	if depth > maxFragmentDepth {
+		return 0
+	}
+
+	count := 0
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name != "__typename" {
+				count++
+			}
+		case *ast.InlineFragment:
+			count += countFields(selection.SelectionSet, fragments, depth+1)
+		case *ast.FragmentSpread:
+			if fragment := fragments.ForName(selection.Name); fragment != nil {
+				count += countFields(fragment.SelectionSet, fragments, depth+1)
+			}
+		}
+	}
+	return count
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
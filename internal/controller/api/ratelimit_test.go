@@ -0,0 +1,228 @@
+package api_test
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var rateLimits = config.HTTPConfig{RateLimit: config.RateLimitConfig{
+	APIPerMinute:     600,
+	APIBurst:         60,
+	GraphQLPerMinute: 600,
+	GraphQLBurst:     60,
+}}
+
+var deniedResult = ratelimit.Result{Allowed: false, Limit: 60, Remaining: 0, RetryAfter: 1500 * time.Millisecond, ResetAfter: 6 * time.Second}
+
+func TestHandler_GraphQLCost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCost int
+	}{
+		{
+			name:         "single field",
+			body:         `{"query":"{ person(id: 1) { id name } }"}`,
+			expectedCost: 1,
+		},
+		{
+			name:         "several root fields",
+			body:         `{"query":"{ a: person(id: 1) { id } b: person(id: 2) { id } getPeople { id } }"}`,
+			expectedCost: 3,
+		},
+		{
+			name:         "typename is free",
+			body:         `{"query":"{ __typename person(id: 1) { id } }"}`,
+			expectedCost: 1,
+		},
+		{
+			name:         "only typename",
+			body:         `{"query":"{ __typename }"}`,
+			expectedCost: 1,
+		},
+		{
+			name:         "fragments are expanded",
+			body:         `{"query":"query { ...Two ... on Query { trash { id } } } fragment Two on Query { a: person(id: 1) { id } b: person(id: 2) { id } }"}`,
+			expectedCost: 3,
+		},
+		{
+			name:         "fragments spreading each other",
+			body:         `{"query":"query { ...A } fragment A on Query { person(id: 1) { id } ...B } fragment B on Query { ...A }"}`,
+			expectedCost: 5,
+		},
+		{
+			name:         "operation picked by name",
+			body:         `{"query":"query One { person(id: 1) { id } } mutation Two { a: deletePerson(id: 1) b: deletePerson(id: 2) }","operationName":"Two"}`,
+			expectedCost: 2,
+		},
+		{
+			name:         "unknown operation",
+			body:         `{"query":"query One { a: person(id: 1) { id } b: person(id: 2) { id } }","operationName":"Two"}`,
+			expectedCost: 1,
+		},
+		{
+			name:         "invalid query",
+			body:         `{"query":"{ person(id: "}`,
+			expectedCost: 1,
+		},
+		{
+			name:         "invalid body",
+			body:         `query`,
+			expectedCost: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, rateLimits)
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleAdmin}}, nil)
+			m.rateLimiter.EXPECT().
+				Allow(gomock.Any(), "graphql:sub:user-1", ratelimit.PerMinute(600, 60), test.expectedCost).
+				Return(deniedResult, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(test.body))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusTooManyRequests, w.Code, "Test case %s failed: Status not as expected", test.name)
+		})
+	}
+}
+
+func TestHandler_GraphQLBodyLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const query = `{"query":"{ person(id: 1) { id } }","padding":"`
+	tests := []struct {
+		name           string
+		size           int
+		expectedStatus int
+	}{
+		{name: "body within the limit", size: 1 << 20, expectedStatus: http.StatusTooManyRequests},
+		{name: "body over the limit", size: 1<<20 + 1, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, rateLimits)
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleAdmin}}, nil)
+			if test.expectedStatus != http.StatusRequestEntityTooLarge {
+				m.rateLimiter.EXPECT().
+					Allow(gomock.Any(), "graphql:sub:user-1", ratelimit.PerMinute(600, 60), 1).
+					Return(deniedResult, nil)
+			}
+
+			body := query + strings.Repeat("x", test.size-len(query)-2) + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+		})
+	}
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name            string
+		result          ratelimit.Result
+		limiterErr      error
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "allowed",
+			result:         ratelimit.Result{Allowed: true, Limit: 60, Remaining: 59, ResetAfter: 100 * time.Millisecond},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "60",
+				"RateLimit-Remaining": "59",
+				"RateLimit-Reset":     "1",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:           "denied",
+			result:         deniedResult,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "60",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "6",
+				"Retry-After":         "2",
+			},
+		},
+		{
+			name:           "limiter fails",
+			limiterErr:     errors.New("connection refused"),
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, rateLimits)
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleReader}}, nil)
+			m.rateLimiter.EXPECT().
+				Allow(gomock.Any(), "api:sub:user-1", ratelimit.PerMinute(600, 60), 1).
+				Return(test.result, test.limiterErr)
+			if test.expectedStatus == http.StatusOK {
+				m.peopleService.EXPECT().GetPeopleStats(gomock.Any(), gomock.Any()).Return(entity.PeopleStats{}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/people/stats", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+			for header, expected := range test.expectedHeaders {
+				assert.Equal(t, expected, w.Header().Get(header), "Test case %s failed: Header %s not as expected", test.name, header)
+			}
+		})
+	}
+}
+
+func TestHandler_RateLimitPerIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.HTTPConfig{RateLimit: config.RateLimitConfig{IPPerMinute: 1200, IPBurst: 120}}
+	h, m := newTestHandler(ctrl, cfg)
+	// The caller is not known yet, so the limit is taken before the token is checked
+	m.rateLimiter.EXPECT().
+		Allow(gomock.Any(), "ip:ip:192.0.2.1", ratelimit.PerMinute(1200, 120), 1).
+		Return(ratelimit.Result{Allowed: false, Limit: 120, RetryAfter: time.Second, ResetAfter: time.Minute}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/people/stats", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	logfields "github.com/khasmag06/effective-mobile-test/pkg/logger"
+	"time"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength bounds the ids accepted from clients, longer ones are replaced.
+	maxRequestIDLength = 128
+)
+
+// requestID takes the request id sent by the client or generates a new one, returns it in the
+// response and adds it to the log fields of the request context.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(logfields.WithFields(c.Request.Context(), "request_id", id))
+		c.Next()
+	}
+}
+
+// validRequestID accepts ids made of letters, digits and the usual separators, so that
+// the id cannot break the log lines or the response headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '/', r == '+', r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// accessLog writes a line per request once it is handled. Requests that matched no route are
+// logged with their path.
+func (h *Handler) accessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		caller := "anonymous"
+		if identity, ok := auth.IdentityFromContext(c.Request.Context()); ok {
+			caller = identity.Subject
+		}
+
+		ctx := logfields.WithFields(c.Request.Context(),
+			"method", c.Request.Method,
+			"route", route,
+			"status", c.Writer.Status(),
+			"latency", time.Since(start).String(),
+			"caller", caller,
+			"client_ip", c.ClientIP(),
+		)
+		h.logger.InfoContext(ctx, "request handled")
+	}
+}
@@ -2,14 +2,59 @@ package api
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"net/http"
+	"net/url"
+	"strconv"
 )
 
+const problemContentType = "application/problem+json"
+
 type successResponse struct {
 	Message string `json:"message" example:"success"`
 }
 
-type errorResponse struct {
-	Error string `json:"error" example:"error message"`
+// problemDetails is an RFC 7807 error response. Code is a stable machine readable error code,
+// Errors lists every failing field of a validation error.
+type problemDetails struct {
+	Type     string                 `json:"type" example:"/problems/validation"`
+	Title    string                 `json:"title" example:"Bad Request"`
+	Status   int                    `json:"status" example:"400"`
+	Detail   string                 `json:"detail" example:"name is a required field"`
+	Instance string                 `json:"instance,omitempty" example:"/api/person/create"`
+	Code     string                 `json:"code" example:"validation"`
+	Errors   []validator.FieldError `json:"errors,omitempty"`
+}
+
+type peoplePageResponse struct {
+	entity.PeoplePage
+	Links pageLinks `json:"links"`
+}
+
+type pageLinks struct {
+	Next string `json:"next,omitempty" example:"/api/v2/people?limit=10&page=3"`
+	Prev string `json:"prev,omitempty" example:"/api/v2/people?limit=10&page=1"`
+}
+
+// newPeoplePageResponse wraps the page with links that repeat the request with the neighbouring page numbers.
+func newPeoplePageResponse(requestURL *url.URL, page entity.PeoplePage) peoplePageResponse {
+	pageURL := func(number int) string {
+		query := requestURL.Query()
+		query.Set("page", strconv.Itoa(number))
+		query.Set("limit", strconv.Itoa(page.Limit))
+		return (&url.URL{Path: requestURL.Path, RawQuery: query.Encode()}).String()
+	}
+
+	resp := peoplePageResponse{PeoplePage: page}
+	if page.Page < page.TotalPages {
+		resp.Links.Next = pageURL(page.Page + 1)
+	}
+	if page.Page > 1 {
+		resp.Links.Prev = pageURL(min(page.Page-1, max(page.TotalPages, 1)))
+	}
+	return resp
 }
 
 func writeSuccessResponse(c *gin.Context, statusCode int, msg string) {
@@ -17,5 +62,56 @@ func writeSuccessResponse(c *gin.Context, statusCode int, msg string) {
 }
 
 func writeErrorResponse(c *gin.Context, statusCode int, msg string) {
-	c.AbortWithStatusJSON(statusCode, errorResponse{Error: msg})
+	writeProblem(c, statusCode, apperr.New(kindOfStatus(statusCode), string(kindOfStatus(statusCode)), msg))
+}
+
+// writeProblem renders err as problem details with the given status. Errors outside the domain
+// taxonomy are reported with the code of the status class.
+func writeProblem(c *gin.Context, statusCode int, err error) {
+	code := apperr.CodeOf(err)
+	if apperr.KindOf(err) == apperr.KindInternal {
+		code = string(kindOfStatus(statusCode))
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(statusCode, problemDetails{
+		Type:     "/problems/" + code,
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   err.Error(),
+		Instance: c.Request.URL.Path,
+		Code:     code,
+		Errors:   apperr.FieldsOf(err),
+	})
+}
+
+// writeServerError reports a failed dependency as 503 and anything else as an opaque 500,
+// the details of err are meant for the logs only.
+func writeServerError(c *gin.Context, err error) {
+	if apperr.KindOf(err) == apperr.KindUnavailable {
+		writeErrorResponse(c, http.StatusServiceUnavailable, "service temporarily unavailable")
+		return
+	}
+	writeErrorResponse(c, http.StatusInternalServerError, "internal server error")
+}
+
+func kindOfStatus(statusCode int) apperr.Kind {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return apperr.KindUnauthenticated
+	case statusCode == http.StatusForbidden:
+		return apperr.KindForbidden
+	case statusCode == http.StatusNotFound:
+		return apperr.KindNotFound
+	case statusCode == http.StatusConflict, statusCode == http.StatusPreconditionFailed:
+		return apperr.KindConflict
+	case statusCode == http.StatusTooManyRequests:
+		return apperr.KindRateLimited
+	case statusCode == http.StatusServiceUnavailable, statusCode == http.StatusGatewayTimeout:
+		return apperr.KindUnavailable
+	case statusCode >= http.StatusInternalServerError:
+		return apperr.KindInternal
+	default:
+		return apperr.KindValidation
+	}
 }
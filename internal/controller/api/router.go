@@ -1,74 +1,220 @@
+//go:generate mockgen -source=$GOFILE -destination=mocks_test.go -package=$GOPACKAGE
 package api
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-gonic/gin"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
 	"github.com/khasmag06/effective-mobile-test/internal/controller/graph"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/pkg/health"
+	"github.com/khasmag06/effective-mobile-test/pkg/ratelimit"
 	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/khasmag06/effective-mobile-test/docs"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-var ErrInvalidID = errors.New("invalid person id")
+var ErrInvalidID = apperr.New(apperr.KindValidation, "invalid_id", "invalid person id")
 
 type peopleService interface {
-	CreatePerson(ctx context.Context, person entity.Person) error
-	UpdatePersonData(ctx context.Context, personID int, person entity.Person) error
-	DeletePersonData(ctx context.Context, personID int) error
-	GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error)
+	CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error)
+	CreatePeople(ctx context.Context, people []entity.Person) (entity.BulkCreateResult, error)
+	ValidatePeople(people []entity.Person) entity.BulkCreateResult
+	UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error)
+	PatchPerson(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error)
+	DeletePersonData(ctx context.Context, personID int, expectedVersion int) error
+	GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error)
+	GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error)
+	GetPeoplePage(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeoplePage, error)
+	GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error)
+	ExportPeople(ctx context.Context, sortBy, sortOrder string, filter entity.PeopleFilter, fn func(entity.Person) error) error
+	SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error)
+	GetPersonByID(ctx context.Context, personID int) (entity.Person, error)
+	GetDeletedPeople(ctx context.Context, page int, limit int) ([]entity.Person, error)
+	RestorePerson(ctx context.Context, personID int) (entity.Person, error)
+	GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error)
+	RevertPerson(ctx context.Context, personID int, revision int) (entity.Person, error)
+}
+
+type idempotencyStore interface {
+	Begin(ctx context.Context, key, requestHash string) (*entity.IdempotentResponse, error)
+	Complete(ctx context.Context, key string, response entity.IdempotentResponse) error
+	Abort(ctx context.Context, key string) error
+}
+
+type apiKeyService interface {
+	IssueAPIKey(ctx context.Context, req entity.APIKeyRequest) (entity.IssuedAPIKey, error)
+	GetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AuthenticateAPIKey(ctx context.Context, key string) (auth.Identity, error)
+}
+
+type tokenVerifier interface {
+	Verify(token string) (auth.Identity, error)
+}
+
+type rateLimiter interface {
+	Allow(ctx context.Context, key string, limit ratelimit.Limit, cost int) (ratelimit.Result, error)
+}
+
+type personEnricher interface {
+	EnrichPerson(ctx context.Context, person *entity.Person) error
+}
+
+type healthChecker interface {
+	Check(ctx context.Context) health.Report
+}
+
+type metrics interface {
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+	ObserveGraphQLOperation(operation string, failed bool, duration time.Duration)
+	Handler() http.Handler
 }
 
 type logger interface {
-	Info(text ...any)
-	Error(text ...any)
-	Errorf(format string, args ...any)
+	InfoContext(ctx context.Context, text ...any)
+	ErrorContext(ctx context.Context, text ...any)
+	ErrorfContext(ctx context.Context, format string, args ...any)
 }
 
 type Handler struct {
 	*gin.Engine
 	*validator.CustomValidator
-	peopleService peopleService
-	logger        logger
+	peopleService    peopleService
+	apiKeyService    apiKeyService
+	personEnricher   personEnricher
+	idempotencyStore idempotencyStore
+	tokenVerifier    tokenVerifier
+	rateLimiter      rateLimiter
+	healthChecker    healthChecker
+	metrics          metrics
+	logger           logger
 }
 
-func NewHandler(ps peopleService, l logger) *Handler {
+func NewHandler(cfg config.HTTPConfig, ps peopleService, ks apiKeyService, pe personEnricher, is idempotencyStore, tv tokenVerifier, rl rateLimiter, hc healthChecker, m metrics, l logger) *Handler {
 	h := &Handler{
-		Engine:          gin.New(),
-		CustomValidator: validator.NewCustomValidator(),
-		peopleService:   ps,
-		logger:          l,
+		Engine:           gin.New(),
+		CustomValidator:  validator.NewCustomValidator(),
+		peopleService:    ps,
+		apiKeyService:    ks,
+		personEnricher:   pe,
+		idempotencyStore: is,
+		tokenVerifier:    tv,
+		rateLimiter:      rl,
+		healthChecker:    hc,
+		metrics:          m,
+		logger:           l,
 	}
 
-	h.Use(gin.Recovery())
+	h.Use(traceRequest(), requestID(), h.accessLog(), h.observe(), gin.Recovery())
 
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(ps, l)}))
+	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  graph.NewResolver(ps, is, l),
+		Directives: graph.DirectiveRoot{HasRole: graph.HasRole},
+	}))
+	srv.AroundResponses(h.observeGraphQL)
+	srv.Use(graph.Tracer{})
 
-	// GraphQL
-	h.GET("/playground", gin.WrapH(playground.Handler("GraphQL playground", "/query")))
+	limits := cfg.RateLimit
+	// Taken before authentication, every caller is known only by its IP at this point
+	perIP := h.rateLimit("ip", ratelimit.PerMinute(limits.IPPerMinute, limits.IPBurst), requestCost)
 
-	h.POST("/query", gin.WrapH(srv))
+	// GraphQL, the roles of the operations are checked by the @hasRole directive
+	h.GET("/playground", perIP, h.authenticate(), h.requireRole(auth.RoleReader),
+		gin.WrapH(playground.Handler("GraphQL playground", "/query")))
+	h.POST("/query", withTimeout(cfg.RequestTimeout), perIP, h.authenticate(), limitBody(maxGraphQLBodySize),
+		h.rateLimit("graphql", ratelimit.PerMinute(limits.GraphQLPerMinute, limits.GraphQLBurst), graphQLCost),
+		withActor(entity.SourceGraphQL), withIdempotencyKey(), gin.WrapH(srv))
 
 	// Swagger
 	h.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	api := h.Group("/api")
-
-	api.GET("people/get", h.getPeople)
-	api.POST("person/create", h.addPerson)
-	api.DELETE("person/delete/:id", h.deletePerson)
-	api.PUT("person/update/:id", h.updatePerson)
+	// Probes and Prometheus
+	h.GET("/healthz", h.liveness)
+	h.GET("/readyz", h.readiness)
+	h.GET("/metrics", gin.WrapH(m.Handler()))
+
+	api := h.Group("/api", perIP, h.authenticate(), withActor(entity.SourceREST))
+	// Export and import stream whole tables and files, the other requests share the shorter deadline.
+	transfer := api.Group("", withTimeout(cfg.ExportTimeout),
+		h.rateLimit("transfer", ratelimit.PerMinute(limits.TransferPerMinute, limits.TransferBurst), requestCost))
+	api = api.Group("", withTimeout(cfg.RequestTimeout),
+		h.rateLimit("api", ratelimit.PerMinute(limits.APIPerMinute, limits.APIBurst), requestCost))
+
+	reader, editor, admin := h.requireRole(auth.RoleReader), h.requireRole(auth.RoleEditor), h.requireRole(auth.RoleAdmin)
+
+	transfer.GET("people/export", reader, h.exportPeople)
+	transfer.POST("people/import", editor, h.importPeople)
+
+	// The person routes of v1 are superseded by the resources of v2
+	api.GET("people/get", deprecated("/api/v2/people"), reader, h.getPeople)
+	api.GET("people/search", reader, h.searchPeople)
+	api.GET("people/stats", reader, h.getPeopleStats)
+	api.GET("people/trash", admin, h.getTrash)
+	api.GET("person/:id", deprecated("/api/v2/people/:id"), reader, h.getPerson)
+	api.POST("person/create", deprecated("/api/v2/people"), editor, h.idempotent("person:create"), h.addPerson)
+	api.POST("people/bulk", editor, h.idempotent("people:bulk"), h.addPeople)
+	api.DELETE("person/delete/:id", deprecated("/api/v2/people/:id"), editor, h.deletePerson)
+	api.PUT("person/update/:id", deprecated("/api/v2/people/:id"), editor, h.updatePerson)
+	api.PATCH("person/:id", deprecated("/api/v2/people/:id"), editor, h.patchPerson)
+	api.POST("person/:id/restore", admin, h.restorePerson)
+	api.GET("person/:id/history", reader, h.getPersonHistory)
+	api.POST("person/:id/history/:revision/revert", editor, h.revertPerson)
+
+	api.POST("apikeys", admin, h.issueAPIKey)
+	api.GET("apikeys", admin, h.getAPIKeys)
+	api.DELETE("apikeys/:id", admin, h.revokeAPIKey)
+
+	apiV2 := api.Group("/v2")
+
+	apiV2.GET("people", reader, h.listPeopleV2)
+	apiV2.POST("people", editor, h.idempotent("people:create"), h.createPersonV2)
+	apiV2.GET("people/:id", reader, h.getPersonV2)
+	apiV2.PUT("people/:id", editor, h.replacePersonV2)
+	apiV2.PATCH("people/:id", editor, h.patchPersonV2)
+	apiV2.DELETE("people/:id", editor, h.deletePersonV2)
 
 	return h
 
 }
 
+// withTimeout sets the overall deadline of the request. Everything done on its behalf through
+// the request context is cancelled once the deadline passes or the client goes away.
+func withTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// deprecatedSince is when the v1 person routes were superseded by the v2 resources.
+var deprecatedSince = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// deprecated marks the responses of a route superseded by successor with the Deprecation header
+// (RFC 9745) and links the successor. An :id in successor is replaced by the id of the request.
+func deprecated(successor string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedSince.Unix(), 10)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, strings.Replace(successor, ":id", c.Param("id"), 1)))
+		c.Next()
+	}
+}
+
 func parseID(idQuery string) (int, error) {
 	id, err := strconv.Atoi(idQuery)
 	if err != nil {
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidIfMatch = apperr.New(apperr.KindValidation, "invalid_if_match", "invalid If-Match header, expected a person version ETag")
+
+// parseIfMatch reads the expected person version from the If-Match header.
+// An absent header or "*" matches any version and yields zero.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	header = strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
+
+// etag renders the person version as a strong entity tag.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedVersion int
+		patchErr        error
+		expectedStatus  int
+		expectedETag    string
+	}{
+		{name: "no header", expectedVersion: 0, expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{name: "any version", ifMatch: "*", expectedVersion: 0, expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{name: "strong etag", ifMatch: `"3"`, expectedVersion: 3, expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{name: "weak etag", ifMatch: `W/"3"`, expectedVersion: 3, expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{name: "unquoted version", ifMatch: "3", expectedVersion: 3, expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{name: "surrounding spaces", ifMatch: ` "3" `, expectedVersion: 3, expectedStatus: http.StatusOK, expectedETag: `"4"`},
+		{name: "stale version", ifMatch: `"2"`, expectedVersion: 2, patchErr: repoerrs.ErrConflict, expectedStatus: http.StatusPreconditionFailed},
+		{name: "zero version", ifMatch: `"0"`, expectedStatus: http.StatusBadRequest},
+		{name: "negative version", ifMatch: `"-1"`, expectedStatus: http.StatusBadRequest},
+		{name: "not a version", ifMatch: `"abc"`, expectedStatus: http.StatusBadRequest},
+		{name: "empty weak etag", ifMatch: `W/""`, expectedStatus: http.StatusBadRequest},
+		{name: "several etags", ifMatch: `"3", "4"`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, m := newTestHandler(ctrl, config.HTTPConfig{})
+			m.tokenVerifier.EXPECT().Verify("token").Return(auth.Identity{Subject: "user-1", Roles: []auth.Role{auth.RoleEditor}}, nil)
+			if test.expectedStatus != http.StatusBadRequest {
+				patched := createdPerson
+				patched.Age, patched.Version = 31, 4
+				m.peopleService.EXPECT().PatchPerson(gomock.Any(), 7, gomock.Any(), test.expectedVersion).Return(patched, test.patchErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v2/people/7", strings.NewReader(`{"age":31}`))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("Content-Type", "application/json")
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, "Test case %s failed: Status not as expected", test.name)
+			assert.Equal(t, test.expectedETag, w.Header().Get("ETag"), "Test case %s failed: ETag not as expected", test.name)
+			if test.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), "invalid_if_match", "Test case %s failed: Body not as expected", test.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package graph
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/controller/graph/model"
+	"strings"
+)
+
+// HasRole implements the @hasRole directive: the field resolves only for callers that have the role.
+func HasRole(ctx context.Context, _ interface{}, next graphql.Resolver, role model.Role) (interface{}, error) {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		return nil, toGraphError(ctx, auth.ErrUnauthenticated, "")
+	}
+	if !identity.HasRole(auth.Role(strings.ToLower(role.String()))) {
+		return nil, toGraphError(ctx, auth.ErrForbidden, "")
+	}
+	return next(ctx)
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/idempotency"
+	"net/http"
+)
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the Idempotency-Key of the GraphQL request.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func idempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// idempotent runs the mutation once per Idempotency-Key of the request and caller. A repeated key with the
+// same input returns the remembered result, a key reused with a different input is rejected.
+// Without a key the mutation simply runs.
+func idempotent[T any](ctx context.Context, r *Resolver, scope string, input any, mutation func() (*T, error)) (*T, error) {
+	key := idempotencyKeyFromContext(ctx)
+	if key == "" {
+		return mutation()
+	}
+	identity, _ := auth.IdentityFromContext(ctx)
+	key = idempotency.Key(scope, identity.Subject, key)
+
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(inputJSON)
+	requestHash := hex.EncodeToString(hash[:])
+
+	stored, err := r.idempotencyStore.Begin(ctx, key, requestHash)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to check idempotency key: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+	if stored != nil {
+		var result T
+		if err := json.Unmarshal(stored.Body, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+
+	result, err := mutation()
+
+	// The client may be gone or the deadline passed by now, the key has to be finished anyway.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotency.FinishTimeout)
+	defer cancel()
+	if err != nil {
+		if abortErr := r.idempotencyStore.Abort(ctx, key); abortErr != nil {
+			r.logger.ErrorContext(ctx, abortErr.Error())
+		}
+		return nil, err
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	response := entity.IdempotentResponse{RequestHash: requestHash, Status: http.StatusOK, Body: resultJSON}
+	if err := r.idempotencyStore.Complete(ctx, key, response); err != nil {
+		r.logger.ErrorContext(ctx, err.Error())
+	}
+	return result, nil
+}
@@ -2,6 +2,72 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Counts the people from the age from up to but not including to, the last bucket has no upper bound.
+type AgeBucket struct {
+	From  int  `json:"from"`
+	To    *int `json:"to,omitempty"`
+	Count int  `json:"count"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type GenderCount struct {
+	Gender string `json:"gender"`
+	Count  int    `json:"count"`
+}
+
+// Counts the people created in the period starting at period (UTC), cumulative includes all the previous periods.
+type GrowthPoint struct {
+	Period     time.Time `json:"period"`
+	Count      int       `json:"count"`
+	Cumulative int       `json:"cumulative"`
+}
+
+// The ages of the people without a known age are left out of the average and the median.
+type NationalityStats struct {
+	Nationality string  `json:"nationality"`
+	Count       int     `json:"count"`
+	AverageAge  float64 `json:"averageAge"`
+	MedianAge   float64 `json:"medianAge"`
+}
+
+type PeopleCursorPage struct {
+	People     []*Person `json:"people"`
+	NextCursor *string   `json:"nextCursor,omitempty"`
+	PrevCursor *string   `json:"prevCursor,omitempty"`
+}
+
+type PeopleFilterInput struct {
+	Name        *string    `json:"name,omitempty"`
+	Surname     *string    `json:"surname,omitempty"`
+	Patronymic  *string    `json:"patronymic,omitempty"`
+	AgeFrom     *int       `json:"ageFrom,omitempty"`
+	AgeTo       *int       `json:"ageTo,omitempty"`
+	Gender      *string    `json:"gender,omitempty"`
+	Nationality []string   `json:"nationality,omitempty"`
+	CreatedFrom *time.Time `json:"createdFrom,omitempty"`
+	CreatedTo   *time.Time `json:"createdTo,omitempty"`
+}
+
+type PeopleStats struct {
+	Total         int                 `json:"total"`
+	Genders       []*GenderCount      `json:"genders"`
+	Nationalities []*NationalityStats `json:"nationalities"`
+	AgeHistogram  []*AgeBucket        `json:"ageHistogram"`
+	Growth        []*GrowthPoint      `json:"growth"`
+}
+
 type Person struct {
 	ID          *int    `json:"id,omitempty"`
 	Name        string  `json:"name"`
@@ -10,6 +76,12 @@ type Person struct {
 	Age         int     `json:"age"`
 	Gender      string  `json:"gender"`
 	Nationality string  `json:"nationality"`
+	Version     *int    `json:"version,omitempty"`
+	// Null in the history snapshots recorded before the timestamps were kept.
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time        `json:"updatedAt,omitempty"`
+	DeletedAt *time.Time        `json:"deletedAt,omitempty"`
+	History   []*PersonRevision `json:"history"`
 }
 
 type PersonInput struct {
@@ -20,3 +92,117 @@ type PersonInput struct {
 	Gender      string  `json:"gender"`
 	Nationality string  `json:"nationality"`
 }
+
+// Fields left out (or null) keep their stored values. Send an empty patronymic to clear it.
+type PersonPatchInput struct {
+	Name        *string `json:"name,omitempty"`
+	Surname     *string `json:"surname,omitempty"`
+	Patronymic  *string `json:"patronymic,omitempty"`
+	Age         *int    `json:"age,omitempty"`
+	Gender      *string `json:"gender,omitempty"`
+	Nationality *string `json:"nationality,omitempty"`
+}
+
+type PersonRevision struct {
+	Revision  int            `json:"revision"`
+	Operation string         `json:"operation"`
+	Actor     string         `json:"actor"`
+	Source    string         `json:"source"`
+	ChangedAt time.Time      `json:"changedAt"`
+	Before    *Person        `json:"before,omitempty"`
+	After     *Person        `json:"after"`
+	Diff      []*FieldChange `json:"diff"`
+}
+
+type PersonSearchResult struct {
+	Person *Person `json:"person"`
+	Score  float64 `json:"score"`
+}
+
+type GrowthInterval string
+
+const (
+	GrowthIntervalDay   GrowthInterval = "DAY"
+	GrowthIntervalWeek  GrowthInterval = "WEEK"
+	GrowthIntervalMonth GrowthInterval = "MONTH"
+	GrowthIntervalYear  GrowthInterval = "YEAR"
+)
+
+var AllGrowthInterval = []GrowthInterval{
+	GrowthIntervalDay,
+	GrowthIntervalWeek,
+	GrowthIntervalMonth,
+	GrowthIntervalYear,
+}
+
+func (e GrowthInterval) IsValid() bool {
+	switch e {
+	case GrowthIntervalDay, GrowthIntervalWeek, GrowthIntervalMonth, GrowthIntervalYear:
+		return true
+	}
+	return false
+}
+
+func (e GrowthInterval) String() string {
+	return string(e)
+}
+
+func (e *GrowthInterval) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = GrowthInterval(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid GrowthInterval", str)
+	}
+	return nil
+}
+
+func (e GrowthInterval) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleReader Role = "READER"
+	RoleEditor Role = "EDITOR"
+	RoleAdmin  Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleReader,
+	RoleEditor,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleReader, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
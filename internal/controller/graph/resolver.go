@@ -2,33 +2,232 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/controller/graph/model"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
 	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"time"
+)
+
+const (
+	errCodeNotFound        = "NOT_FOUND"
+	errCodeBadUserInput    = "BAD_USER_INPUT"
+	errCodeConflict        = "CONFLICT"
+	errCodeUnauthenticated = "UNAUTHENTICATED"
+	errCodeForbidden       = "FORBIDDEN"
+	errCodeUnavailable     = "UNAVAILABLE"
+	errCodeInternal        = "INTERNAL_SERVER_ERROR"
 )
 
 type peopleService interface {
-	CreatePerson(ctx context.Context, person entity.Person) error
-	UpdatePersonData(ctx context.Context, personID int, person entity.Person) error
-	DeletePersonData(ctx context.Context, personID int) error
-	GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error)
+	CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error)
+	UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error)
+	PatchPerson(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error)
+	DeletePersonData(ctx context.Context, personID int, expectedVersion int) error
+	GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error)
+	GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error)
+	SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error)
+	GetPersonByID(ctx context.Context, personID int) (entity.Person, error)
+	GetDeletedPeople(ctx context.Context, page int, limit int) ([]entity.Person, error)
+	RestorePerson(ctx context.Context, personID int) (entity.Person, error)
+	GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error)
+	RevertPerson(ctx context.Context, personID int, revision int) (entity.Person, error)
+	GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error)
+}
+
+type idempotencyStore interface {
+	Begin(ctx context.Context, key, requestHash string) (*entity.IdempotentResponse, error)
+	Complete(ctx context.Context, key string, response entity.IdempotentResponse) error
+	Abort(ctx context.Context, key string) error
 }
 
 type logger interface {
-	Info(text ...any)
-	Error(text ...any)
-	Errorf(format string, args ...any)
+	ErrorContext(ctx context.Context, text ...any)
+	ErrorfContext(ctx context.Context, format string, args ...any)
 }
 
 type Resolver struct {
 	*validator.CustomValidator
-	peopleService peopleService
-	logger        logger
+	peopleService    peopleService
+	idempotencyStore idempotencyStore
+	logger           logger
 }
 
-func NewResolver(ps peopleService, l logger) *Resolver {
+func NewResolver(ps peopleService, is idempotencyStore, l logger) *Resolver {
 	return &Resolver{
-		CustomValidator: validator.NewCustomValidator(),
-		peopleService:   ps,
-		logger:          l,
+		CustomValidator:  validator.NewCustomValidator(),
+		peopleService:    ps,
+		idempotencyStore: is,
+		logger:           l,
+	}
+}
+
+func newPersonModel(person entity.Person) *model.Person {
+	return &model.Person{
+		ID:          &person.ID,
+		Name:        person.Name,
+		Surname:     person.Surname,
+		Patronymic:  &person.Patronymic,
+		Age:         person.Age,
+		Gender:      person.Gender,
+		Nationality: person.Nationality,
+		Version:     &person.Version,
+		CreatedAt:   timeOrNil(person.CreatedAt),
+		UpdatedAt:   timeOrNil(person.UpdatedAt),
+		DeletedAt:   person.DeletedAt,
+	}
+}
+
+// newPersonFromInput converts the mutation input, the patronymic left out is stored empty.
+func newPersonFromInput(input model.PersonInput) entity.Person {
+	person := entity.Person{
+		Name:        input.Name,
+		Surname:     input.Surname,
+		Age:         input.Age,
+		Gender:      input.Gender,
+		Nationality: input.Nationality,
+	}
+	if input.Patronymic != nil {
+		person.Patronymic = *input.Patronymic
+	}
+	return person
+}
+
+// timeOrNil leaves out the timestamps missing from the older history snapshots.
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newPersonRevisionModel(revision entity.PersonRevision) *model.PersonRevision {
+	result := &model.PersonRevision{
+		Revision:  revision.Revision,
+		Operation: revision.Operation,
+		Actor:     revision.Name,
+		Source:    revision.Source,
+		ChangedAt: revision.ChangedAt,
+		After:     newPersonModel(revision.After),
+		Diff:      make([]*model.FieldChange, 0, len(revision.Diff)),
+	}
+	if revision.Before != nil {
+		result.Before = newPersonModel(*revision.Before)
+	}
+	for _, change := range revision.Diff {
+		result.Diff = append(result.Diff, &model.FieldChange{Field: change.Field, From: change.From, To: change.To})
+	}
+	return result
+}
+
+func newPeopleStatsModel(stats entity.PeopleStats) *model.PeopleStats {
+	result := &model.PeopleStats{
+		Total:         stats.Total,
+		Genders:       make([]*model.GenderCount, 0, len(stats.Genders)),
+		Nationalities: make([]*model.NationalityStats, 0, len(stats.Nationalities)),
+		AgeHistogram:  make([]*model.AgeBucket, 0, len(stats.AgeHistogram)),
+		Growth:        make([]*model.GrowthPoint, 0, len(stats.Growth)),
+	}
+	for _, gender := range stats.Genders {
+		result.Genders = append(result.Genders, &model.GenderCount{Gender: gender.Gender, Count: gender.Count})
+	}
+	for _, nationality := range stats.Nationalities {
+		result.Nationalities = append(result.Nationalities, &model.NationalityStats{
+			Nationality: nationality.Nationality,
+			Count:       nationality.Count,
+			AverageAge:  nationality.AverageAge,
+			MedianAge:   nationality.MedianAge,
+		})
+	}
+	for _, bucket := range stats.AgeHistogram {
+		result.AgeHistogram = append(result.AgeHistogram, &model.AgeBucket{From: bucket.From, To: bucket.To, Count: bucket.Count})
+	}
+	for _, point := range stats.Growth {
+		result.Growth = append(result.Growth, &model.GrowthPoint{Period: point.Period, Count: point.Count, Cumulative: point.Cumulative})
+	}
+	return result
+}
+
+// versionArg turns an optional expectedVersion argument into the service form, where zero matches any version.
+func versionArg(expectedVersion *int) int {
+	if expectedVersion == nil {
+		return 0
+	}
+	return *expectedVersion
+}
+
+func newGraphError(ctx context.Context, err error, code string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    err.Error(),
+		Path:       graphql.GetPath(ctx),
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// toGraphError maps err to a GraphQL error with the code of its kind. Every failing field of a
+// validation error becomes a separate error carrying the field path under the given argument,
+// so that clients can show all of them at once. Internal errors are reported without details.
+func toGraphError(ctx context.Context, err error, argument string) error {
+	switch apperr.KindOf(err) {
+	case apperr.KindValidation:
+		fields := apperr.FieldsOf(err)
+		if len(fields) == 0 {
+			return newGraphError(ctx, err, errCodeBadUserInput)
+		}
+		for _, field := range fields[1:] {
+			graphql.AddError(ctx, newFieldError(ctx, argument, field))
+		}
+		return newFieldError(ctx, argument, fields[0])
+	case apperr.KindUnauthenticated:
+		return newGraphError(ctx, err, errCodeUnauthenticated)
+	case apperr.KindForbidden:
+		return newGraphError(ctx, err, errCodeForbidden)
+	case apperr.KindNotFound:
+		return newGraphError(ctx, err, errCodeNotFound)
+	case apperr.KindConflict:
+		return newGraphError(ctx, err, errCodeConflict)
+	case apperr.KindUnavailable:
+		return newGraphError(ctx, errors.New("service temporarily unavailable"), errCodeUnavailable)
+	default:
+		return newGraphError(ctx, errors.New("internal server error"), errCodeInternal)
+	}
+}
+
+func newFieldError(ctx context.Context, argument string, field validator.FieldError) *gqlerror.Error {
+	path := field.Field
+	if argument != "" {
+		path = argument + "." + path
+	}
+	graphErr := newGraphError(ctx, errors.New(field.Message), errCodeBadUserInput)
+	graphErr.Extensions["field"] = path
+	return graphErr
+}
+
+func newPeopleFilter(input *model.PeopleFilterInput) entity.PeopleFilter {
+	if input == nil {
+		return entity.PeopleFilter{}
+	}
+	filter := entity.PeopleFilter{
+		AgeFrom:       input.AgeFrom,
+		AgeTo:         input.AgeTo,
+		Nationalities: input.Nationality,
+		CreatedFrom:   input.CreatedFrom,
+		CreatedTo:     input.CreatedTo,
+	}
+	if input.Name != nil {
+		filter.Name = *input.Name
+	}
+	if input.Surname != nil {
+		filter.Surname = *input.Surname
+	}
+	if input.Patronymic != nil {
+		filter.Patronymic = *input.Patronymic
+	}
+	if input.Gender != nil {
+		filter.Gender = *input.Gender
 	}
+	return filter
 }
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"github.com/khasmag06/effective-mobile-test/internal/controller/graph/model"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
-	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"strings"
 )
 
 const (
@@ -15,82 +15,113 @@ const (
 
 // CreatePerson is the resolver for the createPerson field.
 func (r *Resolver) CreatePerson(ctx context.Context, input model.PersonInput) (*model.Person, error) {
-	newPerson := entity.Person{
-		Name:        input.Name,
-		Surname:     input.Surname,
-		Patronymic:  *input.Patronymic,
-		Age:         input.Age,
-		Gender:      input.Gender,
-		Nationality: input.Nationality,
-	}
+	newPerson := newPersonFromInput(input)
 
 	if err := r.Validate(newPerson); err != nil {
-		r.logger.Errorf("validation err: %v", err)
-		return nil, err
-	}
-	if err := r.peopleService.CreatePerson(ctx, newPerson); err != nil {
-		r.logger.Errorf("failed to create person data: %v", err.Error())
-		return nil, err
+		r.logger.ErrorfContext(ctx, "validation err: %v", err)
+		return nil, toGraphError(ctx, err, "input")
 	}
 
-	return &model.Person{
-		Name:        newPerson.Name,
-		Surname:     newPerson.Surname,
-		Patronymic:  &newPerson.Patronymic,
-		Age:         newPerson.Age,
-		Gender:      newPerson.Gender,
-		Nationality: newPerson.Nationality}, nil
+	return idempotent(ctx, r, "graphql:createPerson", input, func() (*model.Person, error) {
+		person, err := r.peopleService.CreatePerson(ctx, newPerson)
+		if err != nil {
+			r.logger.ErrorfContext(ctx, "failed to create person data: %v", err.Error())
+			return nil, toGraphError(ctx, err, "input")
+		}
+
+		return newPersonModel(person), nil
+	})
 }
 
 // UpdatePerson is the resolver for the updatePerson field.
-func (r *mutationResolver) UpdatePerson(ctx context.Context, id int, input model.PersonInput) (*model.Person, error) {
-	newPerson := entity.Person{
+func (r *mutationResolver) UpdatePerson(ctx context.Context, id int, input model.PersonInput, expectedVersion *int) (*model.Person, error) {
+	newPerson := newPersonFromInput(input)
+
+	if err := r.Validate(newPerson); err != nil {
+		r.logger.ErrorfContext(ctx, "validation err: %v", err)
+		return nil, toGraphError(ctx, err, "input")
+	}
+	person, err := r.peopleService.UpdatePersonData(ctx, id, newPerson, versionArg(expectedVersion))
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to update person: %v", err)
+		return nil, toGraphError(ctx, err, "input")
+	}
+	return newPersonModel(person), nil
+}
+
+// PatchPerson is the resolver for the patchPerson field.
+func (r *mutationResolver) PatchPerson(ctx context.Context, id int, input model.PersonPatchInput, expectedVersion *int) (*model.Person, error) {
+	patch := entity.PersonPatch{
 		Name:        input.Name,
 		Surname:     input.Surname,
-		Patronymic:  *input.Patronymic,
+		Patronymic:  input.Patronymic,
 		Age:         input.Age,
 		Gender:      input.Gender,
 		Nationality: input.Nationality,
 	}
 
-	if err := r.Validate(newPerson); err != nil {
-		r.logger.Errorf("validation err: %v", err)
-		return nil, err
+	person, err := r.peopleService.PatchPerson(ctx, id, patch, versionArg(expectedVersion))
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to patch person: %v", err)
+		return nil, toGraphError(ctx, err, "input")
 	}
-	if err := r.peopleService.UpdatePersonData(ctx, id, newPerson); err != nil {
-		if errors.Is(err, repoerrs.ErrNotFound) {
-			r.logger.Errorf("person not found: %v", err)
-			return nil, err
-		}
-		r.logger.Errorf("failed to update person: %v", err)
-		return nil, err
-	}
-	return &model.Person{
-		Name:        newPerson.Name,
-		Surname:     newPerson.Surname,
-		Patronymic:  &newPerson.Patronymic,
-		Age:         newPerson.Age,
-		Gender:      newPerson.Gender,
-		Nationality: newPerson.Nationality}, nil
+	return newPersonModel(person), nil
 }
 
 // DeletePerson is the resolver for the deletePerson field.
-func (r *mutationResolver) DeletePerson(ctx context.Context, id int) (*bool, error) {
-	if err := r.peopleService.DeletePersonData(ctx, id); err != nil {
-		if errors.Is(err, repoerrs.ErrNotFound) {
-			r.logger.Errorf("person not found: %v", err)
-			return nil, err
-		}
-		r.logger.Errorf("failed to delete person: %v", err)
-		return nil, err
+func (r *mutationResolver) DeletePerson(ctx context.Context, id int, expectedVersion *int) (*bool, error) {
+	if err := r.peopleService.DeletePersonData(ctx, id, versionArg(expectedVersion)); err != nil {
+		r.logger.ErrorfContext(ctx, "failed to delete person: %v", err)
+		return nil, toGraphError(ctx, err, "")
 	}
 
 	result := true
 	return &result, nil
 }
 
+// RestorePerson is the resolver for the restorePerson field.
+func (r *mutationResolver) RestorePerson(ctx context.Context, id int) (*model.Person, error) {
+	person, err := r.peopleService.RestorePerson(ctx, id)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to restore person: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	return newPersonModel(person), nil
+}
+
+// RevertPerson is the resolver for the revertPerson field.
+func (r *mutationResolver) RevertPerson(ctx context.Context, id int, revision int) (*model.Person, error) {
+	person, err := r.peopleService.RevertPerson(ctx, id, revision)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to revert person: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	return newPersonModel(person), nil
+}
+
+// History is the resolver for the history field.
+func (r *personResolver) History(ctx context.Context, obj *model.Person) ([]*model.PersonRevision, error) {
+	if obj.ID == nil {
+		return []*model.PersonRevision{}, nil
+	}
+
+	history, err := r.peopleService.GetPersonHistory(ctx, *obj.ID)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to fetch person history: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	result := make([]*model.PersonRevision, 0, len(history))
+	for _, revision := range history {
+		result = append(result, newPersonRevisionModel(revision))
+	}
+	return result, nil
+}
+
 // GetPeople is the resolver for the getPeople field.
-func (r *queryResolver) GetPeople(ctx context.Context, page *int, limit *int, sortBy *string, sortOrder *string) ([]*model.Person, error) {
+func (r *queryResolver) GetPeople(ctx context.Context, page *int, limit *int, sortBy *string, sortOrder *string, filter *model.PeopleFilterInput) ([]*model.Person, error) {
 
 	if page == nil || *page <= 0 {
 		defaultPage := defaultPageNumber
@@ -109,33 +140,165 @@ func (r *queryResolver) GetPeople(ctx context.Context, page *int, limit *int, so
 		sortOrder = &defaultSortOrder
 	}
 
-	people, err := r.peopleService.GetPeople(ctx, *page, *limit, *sortBy, *sortOrder)
+	peopleFilter := newPeopleFilter(filter)
+	if err := r.Validate(peopleFilter); err != nil {
+		r.logger.ErrorfContext(ctx, "validation err: %v", err)
+		return nil, toGraphError(ctx, err, "filter")
+	}
+
+	people, err := r.peopleService.GetPeople(ctx, *page, *limit, *sortBy, *sortOrder, peopleFilter)
 	if err != nil {
-		r.logger.Errorf("failed to fetch people data: %v", err)
-		return nil, err
+		r.logger.ErrorfContext(ctx, "failed to fetch people data: %v", err)
+		return nil, toGraphError(ctx, err, "")
 	}
 
 	var result []*model.Person
 	for _, person := range people {
-		graphqlPerson := &model.Person{
-			ID:          &person.ID,
-			Name:        person.Name,
-			Surname:     person.Surname,
-			Patronymic:  &person.Patronymic,
-			Age:         person.Age,
-			Gender:      person.Gender,
-			Nationality: person.Nationality,
-		}
-		result = append(result, graphqlPerson)
+		result = append(result, newPersonModel(person))
+	}
+	return result, nil
+}
+
+// GetPeopleByCursor is the resolver for the getPeopleByCursor field.
+func (r *queryResolver) GetPeopleByCursor(ctx context.Context, cursor *string, limit *int, sortBy *string, sortOrder *string, filter *model.PeopleFilterInput) (*model.PeopleCursorPage, error) {
+	if cursor == nil {
+		firstPage := ""
+		cursor = &firstPage
+	}
+	if limit == nil || *limit <= 0 {
+		defaultLimit := defaultPaginationLimit
+		limit = &defaultLimit
+	}
+	if sortBy == nil {
+		defaultSortBy := "date"
+		sortBy = &defaultSortBy
+	}
+	if sortOrder == nil {
+		defaultSortOrder := "asc"
+		sortOrder = &defaultSortOrder
+	}
+
+	peopleFilter := newPeopleFilter(filter)
+	if err := r.Validate(peopleFilter); err != nil {
+		r.logger.ErrorfContext(ctx, "validation err: %v", err)
+		return nil, toGraphError(ctx, err, "filter")
+	}
+
+	page, err := r.peopleService.GetPeopleByCursor(ctx, *cursor, *limit, *sortBy, *sortOrder, peopleFilter)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to fetch people data: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	result := &model.PeopleCursorPage{People: make([]*model.Person, 0, len(page.People))}
+	for _, person := range page.People {
+		result.People = append(result.People, newPersonModel(person))
+	}
+	if page.NextCursor != "" {
+		result.NextCursor = &page.NextCursor
+	}
+	if page.PrevCursor != "" {
+		result.PrevCursor = &page.PrevCursor
+	}
+	return result, nil
+}
+
+// Person is the resolver for the person field.
+func (r *queryResolver) Person(ctx context.Context, id int) (*model.Person, error) {
+	person, err := r.peopleService.GetPersonByID(ctx, id)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to fetch person data: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	return newPersonModel(person), nil
+}
+
+// SearchPeople is the resolver for the searchPeople field.
+func (r *queryResolver) SearchPeople(ctx context.Context, query string, limit *int) ([]*model.PersonSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, newGraphError(ctx, errors.New("query is required"), errCodeBadUserInput)
+	}
+	if limit == nil || *limit <= 0 {
+		defaultLimit := defaultPaginationLimit
+		limit = &defaultLimit
+	}
+
+	results, err := r.peopleService.SearchPeople(ctx, query, *limit)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to search people data: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	searchResults := make([]*model.PersonSearchResult, 0, len(results))
+	for _, result := range results {
+		searchResults = append(searchResults, &model.PersonSearchResult{
+			Person: newPersonModel(result.Person),
+			Score:  result.Score,
+		})
+	}
+	return searchResults, nil
+}
+
+// Trash is the resolver for the trash field.
+func (r *queryResolver) Trash(ctx context.Context, page *int, limit *int) ([]*model.Person, error) {
+	if page == nil || *page <= 0 {
+		defaultPage := defaultPageNumber
+		page = &defaultPage
+	}
+	if limit == nil || *limit <= 0 {
+		defaultLimit := defaultPaginationLimit
+		limit = &defaultLimit
+	}
+
+	people, err := r.peopleService.GetDeletedPeople(ctx, *page, *limit)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to fetch deleted people data: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	result := make([]*model.Person, 0, len(people))
+	for _, person := range people {
+		result = append(result, newPersonModel(person))
 	}
 	return result, nil
 }
 
+// PeopleStats is the resolver for the peopleStats field.
+func (r *queryResolver) PeopleStats(ctx context.Context, filter *model.PeopleFilterInput, topNationalities *int, ageBuckets []int, growthInterval *model.GrowthInterval) (*model.PeopleStats, error) {
+	peopleFilter := newPeopleFilter(filter)
+	if err := r.Validate(peopleFilter); err != nil {
+		r.logger.ErrorfContext(ctx, "validation err: %v", err)
+		return nil, toGraphError(ctx, err, "filter")
+	}
+
+	query := entity.PeopleStatsQuery{Filter: peopleFilter, AgeBuckets: ageBuckets}
+	if topNationalities != nil {
+		query.TopNationalities = *topNationalities
+	}
+	if growthInterval != nil {
+		query.GrowthInterval = strings.ToLower(growthInterval.String())
+	}
+
+	stats, err := r.peopleService.GetPeopleStats(ctx, query)
+	if err != nil {
+		r.logger.ErrorfContext(ctx, "failed to compute people stats: %v", err)
+		return nil, toGraphError(ctx, err, "")
+	}
+
+	return newPeopleStatsModel(stats), nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Person returns PersonResolver implementation.
+func (r *Resolver) Person() PersonResolver { return &personResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
 type mutationResolver struct{ *Resolver }
+type personResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+var tracer = otel.Tracer("github.com/khasmag06/effective-mobile-test/internal/controller/graph")
+
+// Tracer is a gqlgen extension that wraps every operation and every resolver call in a span.
+// Fields read from the parent object are not traced, they do no work of their own.
+type Tracer struct{}
+
+var (
+	_ graphql.HandlerExtension    = Tracer{}
+	_ graphql.ResponseInterceptor = Tracer{}
+	_ graphql.FieldInterceptor    = Tracer{}
+)
+
+func (Tracer) ExtensionName() string {
+	return "Tracer"
+}
+
+func (Tracer) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+
+	name := "GraphQL operation"
+	var attributes []attribute.KeyValue
+	if oc.Operation != nil {
+		attributes = append(attributes, semconv.GraphqlOperationTypeKey.String(string(oc.Operation.Operation)))
+		if oc.Operation.Name != "" {
+			name = "GraphQL " + string(oc.Operation.Operation) + " " + oc.Operation.Name
+			attributes = append(attributes, semconv.GraphqlOperationName(oc.Operation.Name))
+		}
+	}
+
+	ctx, span := tracer.Start(ctx, name)
+	span.SetAttributes(attributes...)
+	defer span.End()
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetStatus(codes.Error, resp.Errors.Error())
+	}
+	return resp
+}
+
+func (Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, fc.Object+"."+fc.Field.Name)
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// APIKey is a long-lived credential of a service client. Only the hash of the key is stored,
+// the prefix of the key identifies it in listings.
+type APIKey struct {
+	ID         int        `json:"id" example:"1"`
+	Name       string     `json:"name" example:"nightly-import"`
+	Prefix     string     `json:"prefix" example:"fio_Jx2kQ9"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes" example:"reader,editor"`
+	CreatedAt  time.Time  `json:"createdAt" example:"2023-10-01T12:00:00Z"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" example:"2024-10-01T12:00:00Z"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" example:"2023-10-02T03:00:00Z"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=255" example:"nightly-import"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=reader editor admin" example:"reader,editor"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" example:"2024-10-01T12:00:00Z"`
+}
+
+// IssuedAPIKey is returned once, when the key is created. The key itself cannot be retrieved later.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key" example:"fio_Jx2kQ9..."`
+}
@@ -0,0 +1,24 @@
+package entity
+
+const (
+	BulkStatusCreated = "created"
+	BulkStatusValid   = "valid"
+	BulkStatusInvalid = "invalid"
+)
+
+type BulkItemResult struct {
+	Index  int    `json:"index" example:"0"`
+	Status string `json:"status" example:"invalid"`
+	Error  string `json:"error,omitempty" example:"field name is required"`
+}
+
+type BulkCreateResult struct {
+	Created int              `json:"created" example:"999"`
+	Failed  int              `json:"failed" example:"1"`
+	Results []BulkItemResult `json:"results"`
+}
+
+type ImportReport struct {
+	DryRun bool `json:"dryRun" example:"true"`
+	BulkCreateResult
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+type PeopleFilter struct {
+	Name          string     `json:"name,omitempty" form:"name" example:"Iv"`
+	Surname       string     `json:"surname,omitempty" form:"surname" example:"Ivan"`
+	Patronymic    string     `json:"patronymic,omitempty" form:"patronymic" example:"Serg"`
+	AgeFrom       *int       `json:"ageFrom,omitempty" form:"ageFrom" validate:"omitempty,gte=0,lte=120" example:"18"`
+	AgeTo         *int       `json:"ageTo,omitempty" form:"ageTo" validate:"omitempty,gte=0,lte=120" example:"65"`
+	Gender        string     `json:"gender,omitempty" form:"gender" validate:"omitempty,oneof=male female" example:"male"`
+	Nationalities []string   `json:"nationality,omitempty" form:"nationality" validate:"dive,alpha" example:"RU"`
+	CreatedFrom   *time.Time `json:"createdFrom,omitempty" form:"createdFrom" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo     *time.Time `json:"createdTo,omitempty" form:"createdTo" time_format:"2006-01-02T15:04:05Z07:00"`
+}
@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+	OperationRevert  = "revert"
+)
+
+const (
+	SourceREST    = "rest"
+	SourceGraphQL = "graphql"
+	SourceKafka   = "kafka"
+	SourceSystem  = "system"
+)
+
+// Actor is who made a change and through which entry point.
+type Actor struct {
+	Name   string `json:"actor" example:"127.0.0.1"`
+	Source string `json:"source" example:"rest"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that carries the actor of the changes made with it.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, changes made without one are attributed to the system.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Name: SourceSystem, Source: SourceSystem}
+}
+
+// PersonRevision is a single recorded change of a person. The revision number is the person
+// version the change produced.
+type PersonRevision struct {
+	PersonID  int    `json:"personId" example:"1"`
+	Revision  int    `json:"revision" example:"2"`
+	Operation string `json:"operation" example:"update"`
+	Actor
+	ChangedAt time.Time     `json:"changedAt" example:"2023-10-01T12:00:00Z"`
+	Before    *Person       `json:"before,omitempty"`
+	After     Person        `json:"after"`
+	Diff      []FieldChange `json:"diff"`
+}
+
+type FieldChange struct {
+	Field string `json:"field" example:"age"`
+	From  string `json:"from" example:"70"`
+	To    string `json:"to" example:"71"`
+}
+
+// DiffPeople lists the fields whose values differ between the two snapshots.
+// A nil before is treated as an empty person.
+func DiffPeople(before *Person, after Person) []FieldChange {
+	var from Person
+	if before != nil {
+		from = *before
+	}
+
+	changes := []FieldChange{}
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, From: oldValue, To: newValue})
+		}
+	}
+	add("name", from.Name, after.Name)
+	add("surname", from.Surname, after.Surname)
+	add("patronymic", from.Patronymic, after.Patronymic)
+	add("age", strconv.Itoa(from.Age), strconv.Itoa(after.Age))
+	add("gender", from.Gender, after.Gender)
+	add("nationality", from.Nationality, after.Nationality)
+	add("deletedAt", formatTime(from.DeletedAt), formatTime(after.DeletedAt))
+
+	return changes
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "encoding/json"
+
+// IdempotentResponse is what is remembered under an Idempotency-Key: the hash of the request that
+// used the key and, once the request has finished, its response. A zero status means the request
+// is still in progress.
+type IdempotentResponse struct {
+	RequestHash string          `json:"requestHash"`
+	Status      int             `json:"status,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+	Location    string          `json:"location,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+// MaxPaginationLimit is the largest number of people returned on a single page.
+const MaxPaginationLimit = 10
+
+type PeoplePage struct {
+	Items      []Person `json:"items"`
+	Total      int      `json:"total" example:"42"`
+	Page       int      `json:"page" example:"1"`
+	Limit      int      `json:"limit" example:"10"`
+	TotalPages int      `json:"totalPages" example:"5"`
+}
+
+type PeopleCursorPage struct {
+	People     []Person `json:"people"`
+	NextCursor string   `json:"nextCursor,omitempty" example:"eyJzIjoiYWdlIiwibyI6ImFzYyIsInYiOiI0MiIsImlkIjoxMH0"`
+	PrevCursor string   `json:"prevCursor,omitempty" example:"eyJzIjoiYWdlIiwibyI6ImFzYyIsInYiOiIxOCIsImlkIjoxLCJiIjp0cnVlfQ"`
+}
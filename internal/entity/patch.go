@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PersonPatch is a JSON Merge Patch (RFC 7396) of a person. A nil field is left unchanged,
+// a field explicitly set to null in the document is reset to its zero value.
+type PersonPatch struct {
+	Name        *string `json:"name,omitempty" example:"Ivan"`
+	Surname     *string `json:"surname,omitempty" example:"Ivanov"`
+	Patronymic  *string `json:"patronymic,omitempty" example:"Sergeevich"`
+	Age         *int    `json:"age,omitempty" example:"70"`
+	Gender      *string `json:"gender,omitempty" example:"male"`
+	Nationality *string `json:"nationality,omitempty" example:"RU"`
+}
+
+func (p *PersonPatch) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	fields := map[string]any{
+		"name":        &p.Name,
+		"surname":     &p.Surname,
+		"patronymic":  &p.Patronymic,
+		"age":         &p.Age,
+		"gender":      &p.Gender,
+		"nationality": &p.Nationality,
+	}
+	for key, value := range doc {
+		field, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if bytes.Equal(value, []byte("null")) {
+			resetField(field)
+			continue
+		}
+		if err := json.Unmarshal(value, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetField points the patch field at the zero value of its type.
+func resetField(field any) {
+	switch f := field.(type) {
+	case **string:
+		*f = new(string)
+	case **int:
+		*f = new(int)
+	}
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (p PersonPatch) IsEmpty() bool {
+	return p == PersonPatch{}
+}
+
+// Apply returns the person with the patch merged into it.
+func (p PersonPatch) Apply(person Person) Person {
+	if p.Name != nil {
+		person.Name = *p.Name
+	}
+	if p.Surname != nil {
+		person.Surname = *p.Surname
+	}
+	if p.Patronymic != nil {
+		person.Patronymic = *p.Patronymic
+	}
+	if p.Age != nil {
+		person.Age = *p.Age
+	}
+	if p.Gender != nil {
+		person.Gender = *p.Gender
+	}
+	if p.Nationality != nil {
+		person.Nationality = *p.Nationality
+	}
+	return person
+}
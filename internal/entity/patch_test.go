@@ -0,0 +1,127 @@
+package entity_test
+
+import (
+	"encoding/json"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPersonPatch_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		expectedPatch entity.PersonPatch
+		expectedEmpty bool
+		expectedErr   bool
+	}{
+		{
+			name:          "empty document",
+			data:          `{}`,
+			expectedEmpty: true,
+		},
+		{
+			name:          "fields set",
+			data:          `{"name":"Petr","age":31,"patronymic":"Sergeevich"}`,
+			expectedPatch: entity.PersonPatch{Name: ptr("Petr"), Patronymic: ptr("Sergeevich"), Age: ptr(31)},
+		},
+		{
+			name:          "fields set to null are reset",
+			data:          `{"patronymic":null,"age":null}`,
+			expectedPatch: entity.PersonPatch{Patronymic: ptr(""), Age: ptr(0)},
+		},
+		{
+			name:          "absent fields are left unchanged",
+			data:          `{"surname":"Petrov"}`,
+			expectedPatch: entity.PersonPatch{Surname: ptr("Petrov")},
+		},
+		{
+			name:          "empty string is set, not reset",
+			data:          `{"patronymic":""}`,
+			expectedPatch: entity.PersonPatch{Patronymic: ptr("")},
+		},
+		{
+			name:          "unknown fields are ignored",
+			data:          `{"id":5,"version":3,"gender":"female"}`,
+			expectedPatch: entity.PersonPatch{Gender: ptr("female")},
+		},
+		{
+			name:          "only unknown fields",
+			data:          `{"id":5}`,
+			expectedEmpty: true,
+		},
+		{
+			name:        "wrong field type",
+			data:        `{"age":"thirty"}`,
+			expectedErr: true,
+		},
+		{
+			name:        "not an object",
+			data:        `["name"]`,
+			expectedErr: true,
+		},
+		{
+			name:          "null document",
+			data:          `null`,
+			expectedEmpty: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patch entity.PersonPatch
+			err := json.Unmarshal([]byte(test.data), &patch)
+
+			if test.expectedErr {
+				assert.Error(t, err, "Test case %s failed: Error not as expected", test.name)
+				return
+			}
+			assert.NoError(t, err, "Test case %s failed: Error not as expected", test.name)
+			assert.Equal(t, test.expectedPatch, patch, "Test case %s failed: Patch not as expected", test.name)
+			assert.Equal(t, test.expectedEmpty, patch.IsEmpty(), "Test case %s failed: Emptiness not as expected", test.name)
+		})
+	}
+}
+
+func TestPersonPatch_Apply(t *testing.T) {
+	person := entity.Person{ID: 7, Name: "Ivan", Surname: "Ivanov", Patronymic: "Sergeevich", Age: 30, Gender: "male", Nationality: "RU", Version: 2}
+
+	tests := []struct {
+		name           string
+		patch          string
+		expectedPerson entity.Person
+	}{
+		{
+			name:           "empty patch",
+			patch:          `{}`,
+			expectedPerson: person,
+		},
+		{
+			name:  "fields changed",
+			patch: `{"name":"Petr","age":31}`,
+			expectedPerson: entity.Person{
+				ID: 7, Name: "Petr", Surname: "Ivanov", Patronymic: "Sergeevich", Age: 31, Gender: "male", Nationality: "RU", Version: 2,
+			},
+		},
+		{
+			name:  "patronymic removed",
+			patch: `{"patronymic":null}`,
+			expectedPerson: entity.Person{
+				ID: 7, Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU", Version: 2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patch entity.PersonPatch
+			assert.NoError(t, json.Unmarshal([]byte(test.patch), &patch))
+
+			assert.Equal(t, test.expectedPerson, patch.Apply(person), "Test case %s failed: Person not as expected", test.name)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package entity
 
+import "time"
+
 type Person struct {
 	ID          int    `json:"id" example:"1"`
 	Name        string `json:"name" validate:"required,alpha,startsWithUpperCase" example:"Ivan"`
@@ -8,4 +10,15 @@ type Person struct {
 	Age         int    `json:"age" validate:"gte=0,lte=120" example:"70"`
 	Gender      string `json:"gender" validate:"oneof=male female" example:"male"`
 	Nationality string `json:"nationality" validate:"alpha" example:"RU"`
+	Version     int    `json:"version,omitempty" example:"1"`
+	// CreatedAt and UpdatedAt are set by the database, the values sent by clients are ignored.
+	CreatedAt time.Time `json:"createdAt" example:"2023-10-01T12:00:00Z"`
+	UpdatedAt time.Time `json:"updatedAt" example:"2023-10-01T12:00:00Z"`
+	// DeletedAt is set while the person is in the trash, before it is purged for good.
+	DeletedAt *time.Time `json:"deletedAt,omitempty" example:"2023-10-01T12:00:00Z"`
+}
+
+type PersonSearchResult struct {
+	Person
+	Score float64 `json:"score" example:"0.83"`
 }
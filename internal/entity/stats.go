@@ -0,0 +1,59 @@
+package entity
+
+import "time"
+
+// Periods the growth of PeopleStats is counted by.
+const (
+	GrowthDay   = "day"
+	GrowthWeek  = "week"
+	GrowthMonth = "month"
+	GrowthYear  = "year"
+)
+
+// PeopleStatsQuery selects the people the statistics are computed over and shapes the result.
+// AgeBuckets are the ascending ages the histogram buckets after the first one start at, the first
+// bucket always starts at zero.
+type PeopleStatsQuery struct {
+	Filter           PeopleFilter `json:"filter"`
+	TopNationalities int          `json:"topNationalities" validate:"gte=1,lte=100"`
+	AgeBuckets       []int        `json:"ageBuckets" validate:"max=20,dive,gte=1,lte=120"`
+	GrowthInterval   string       `json:"growthInterval" validate:"oneof=day week month year"`
+}
+
+type PeopleStats struct {
+	Total         int                `json:"total" example:"42"`
+	Genders       []GenderCount      `json:"genders"`
+	Nationalities []NationalityStats `json:"nationalities"`
+	AgeHistogram  []AgeBucket        `json:"ageHistogram"`
+	Growth        []GrowthPoint      `json:"growth"`
+}
+
+type GenderCount struct {
+	Gender string `json:"gender" example:"male"`
+	Count  int    `json:"count" example:"20"`
+}
+
+// NationalityStats describes one of the most common nationalities, the ages of the people
+// without a known age are left out of the average and the median.
+type NationalityStats struct {
+	Nationality string  `json:"nationality" example:"RU"`
+	Count       int     `json:"count" example:"12"`
+	AverageAge  float64 `json:"averageAge" example:"41.5"`
+	MedianAge   float64 `json:"medianAge" example:"39"`
+}
+
+// AgeBucket counts the people from the age From up to but not including To. The last bucket
+// has no upper bound.
+type AgeBucket struct {
+	From  int  `json:"from" example:"18"`
+	To    *int `json:"to,omitempty" example:"25"`
+	Count int  `json:"count" example:"7"`
+}
+
+// GrowthPoint counts the people created in the period starting at Period, Cumulative adds up
+// the people created in this and all the previous periods.
+type GrowthPoint struct {
+	Period     time.Time `json:"period" example:"2023-10-01T00:00:00Z"`
+	Count      int       `json:"count" example:"5"`
+	Cumulative int       `json:"cumulative" example:"30"`
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/repoerrs"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// repo keeps the api key lookups of the authentication middleware in Redis for a short time.
+// The unknown keys are remembered for missExpiration, so that guessing keys does not load the database.
+// A revoked key is cached as such instead of being dropped, and lookups never replace a cached entry,
+// so a lookup that read the key just before it was revoked cannot bring it back to life.
+type repo struct {
+	repository
+	redis          *redis.Client
+	expiration     time.Duration
+	missExpiration time.Duration
+	metrics        metrics
+	logger         logger
+}
+
+func New(rdb *redis.Client, apiKeyRepo repository, expiration, missExpiration time.Duration, metrics metrics, logger logger) *repo {
+	return &repo{
+		repository:     apiKeyRepo,
+		redis:          rdb,
+		expiration:     expiration,
+		missExpiration: missExpiration,
+		metrics:        metrics,
+		logger:         logger,
+	}
+}
+
+const (
+	// cacheName is the name of the api key cache in the metrics.
+	cacheName = "api_key"
+	// missMarker is cached in place of a key that does not exist.
+	missMarker = "-"
+)
+
+func (r *repo) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	keyCache, err := r.GetAPIKeyFromCache(ctx, hash)
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		r.metrics.CacheHit(cacheName)
+		return entity.APIKey{}, err
+	}
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.ErrorContext(ctx, err)
+	}
+	if keyCache != nil {
+		r.metrics.CacheHit(cacheName)
+		return *keyCache, nil
+	}
+	r.metrics.CacheMiss(cacheName)
+
+	key, err := r.repository.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			if err := r.SaveAPIKeyMissToCache(ctx, hash); err != nil {
+				r.logger.ErrorContext(ctx, err)
+			}
+		}
+		return entity.APIKey{}, err
+	}
+
+	if err := r.SaveAPIKeyToCache(ctx, key); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return key, nil
+}
+
+// CreateAPIKey drops a remembered miss of the new key, in case its hash was looked up before.
+func (r *repo) CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	created, err := r.repository.CreateAPIKey(ctx, key)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+	if err := r.DeleteAPIKeyFromCache(ctx, key.Hash); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return created, nil
+}
+
+// RevokeAPIKey overwrites the cached key with the revoked one, so that it is rejected right away.
+func (r *repo) RevokeAPIKey(ctx context.Context, id int) (entity.APIKey, error) {
+	key, err := r.repository.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+	if err := r.SaveRevokedAPIKeyToCache(ctx, key); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey drops the cached key to pick up its new last usage time, unless the key was revoked meanwhile.
+func (r *repo) TouchAPIKey(ctx context.Context, hash string, usedAt time.Time) error {
+	if err := r.repository.TouchAPIKey(ctx, hash, usedAt); err != nil {
+		return err
+	}
+	if err := r.DeleteActiveAPIKeyFromCache(ctx, hash); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return nil
+}
+
+func apiKeyKey(hash string) string {
+	return "ak:" + hash // ak - api key
+}
+
+// SaveAPIKeyToCache caches the key unless another entry was cached for it meanwhile, which may be
+// the revoked key.
+func (r *repo) SaveAPIKeyToCache(ctx context.Context, key entity.APIKey) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return r.redis.SetNX(ctx, apiKeyKey(key.Hash), keyJSON, r.expiration).Err()
+}
+
+// SaveRevokedAPIKeyToCache replaces whatever is cached for the key with the revoked key.
+func (r *repo) SaveRevokedAPIKeyToCache(ctx context.Context, key entity.APIKey) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.Set(ctx, apiKeyKey(key.Hash), keyJSON, r.expiration).Err(); err != nil {
+		return err
+	}
+	r.metrics.CacheInvalidated(cacheName)
+	return nil
+}
+
+// SaveAPIKeyMissToCache remembers that there is no key with the hash.
+func (r *repo) SaveAPIKeyMissToCache(ctx context.Context, hash string) error {
+	return r.redis.SetNX(ctx, apiKeyKey(hash), missMarker, r.missExpiration).Err()
+}
+
+// GetAPIKeyFromCache returns the cached key, or repoerrs.ErrNotFound if the key is remembered as missing.
+func (r *repo) GetAPIKeyFromCache(ctx context.Context, hash string) (*entity.APIKey, error) {
+	keyJSON, err := r.redis.Get(ctx, apiKeyKey(hash)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if keyJSON == missMarker {
+		return nil, repoerrs.ErrNotFound
+	}
+
+	var keyCache entity.APIKey
+	if err := json.Unmarshal([]byte(keyJSON), &keyCache); err != nil {
+		return nil, err
+	}
+	// the hash is not serialized, it is the cache key
+	keyCache.Hash = hash
+	return &keyCache, nil
+}
+
+// deleteActiveKey deletes the cached key unless it is a revoked one.
+var deleteActiveKey = redis.NewScript(`
+local cached = redis.call('GET', KEYS[1])
+if not cached then
+  return 0
+end
+local ok, key = pcall(cjson.decode, cached)
+if ok and type(key) == 'table' and key.revokedAt then
+  return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// DeleteActiveAPIKeyFromCache drops the cached key, the revoked keys stay cached as such.
+func (r *repo) DeleteActiveAPIKeyFromCache(ctx context.Context, hash string) error {
+	if err := deleteActiveKey.Run(ctx, r.redis, []string{apiKeyKey(hash)}).Err(); err != nil {
+		return err
+	}
+	r.metrics.CacheInvalidated(cacheName)
+	return nil
+}
+
+func (r *repo) DeleteAPIKeyFromCache(ctx context.Context, hash string) error {
+	if err := r.redis.Del(ctx, apiKeyKey(hash)).Err(); err != nil {
+		return err
+	}
+	r.metrics.CacheInvalidated(cacheName)
+	return nil
+}
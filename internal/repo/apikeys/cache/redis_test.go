@@ -0,0 +1,144 @@
+package cache_test
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/cache"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/repoerrs"
+	"github.com/khasmag06/effective-mobile-test/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	expiration     = 30 * time.Second
+	missExpiration = 10 * time.Second
+)
+
+func TestRepo_GetAPIKeyByHash(t *testing.T) {
+	ctx := context.Background()
+	revokedAt := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	active := entity.APIKey{ID: 1, Name: "nightly-import", Prefix: "fio_abcdef", Hash: "hash", Scopes: []string{"editor"}}
+	revoked := active
+	revoked.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, r *mocks, c cacheRepo)
+		expectedKey entity.APIKey
+		expectedErr error
+		expectedTTL time.Duration
+	}{
+		{
+			name: "key is cached",
+			setup: func(t *testing.T, r *mocks, _ cacheRepo) {
+				r.repo.EXPECT().GetAPIKeyByHash(gomock.Any(), "hash").Return(active, nil).Times(1)
+			},
+			expectedKey: active,
+			expectedTTL: expiration,
+		},
+		{
+			name: "unknown key is remembered",
+			setup: func(t *testing.T, r *mocks, _ cacheRepo) {
+				r.repo.EXPECT().GetAPIKeyByHash(gomock.Any(), "hash").Return(entity.APIKey{}, repoerrs.ErrNotFound).Times(1)
+			},
+			expectedErr: repoerrs.ErrNotFound,
+			expectedTTL: missExpiration,
+		},
+		{
+			name: "revoked key is cached as such",
+			setup: func(t *testing.T, r *mocks, c cacheRepo) {
+				r.repo.EXPECT().GetAPIKeyByHash(gomock.Any(), "hash").Return(active, nil)
+				r.repo.EXPECT().RevokeAPIKey(gomock.Any(), 1).Return(revoked, nil)
+				_, err := c.GetAPIKeyByHash(ctx, "hash")
+				require.NoError(t, err)
+				_, err = c.RevokeAPIKey(ctx, 1)
+				require.NoError(t, err)
+			},
+			expectedKey: revoked,
+			expectedTTL: expiration,
+		},
+		{
+			name: "lookup that read the key before the revoke",
+			setup: func(t *testing.T, r *mocks, c cacheRepo) {
+				r.repo.EXPECT().RevokeAPIKey(gomock.Any(), 1).Return(revoked, nil)
+				r.repo.EXPECT().GetAPIKeyByHash(gomock.Any(), "hash").
+					DoAndReturn(func(ctx context.Context, _ string) (entity.APIKey, error) {
+						// The key is revoked after the lookup read it and before the lookup caches it
+						_, err := c.RevokeAPIKey(ctx, 1)
+						require.NoError(t, err)
+						return active, nil
+					})
+				_, err := c.GetAPIKeyByHash(ctx, "hash")
+				require.NoError(t, err)
+			},
+			expectedKey: revoked,
+			expectedTTL: expiration,
+		},
+		{
+			name: "usage of a key that was revoked meanwhile",
+			setup: func(t *testing.T, r *mocks, c cacheRepo) {
+				r.repo.EXPECT().RevokeAPIKey(gomock.Any(), 1).Return(revoked, nil)
+				r.repo.EXPECT().TouchAPIKey(gomock.Any(), "hash", gomock.Any()).Return(nil)
+				_, err := c.RevokeAPIKey(ctx, 1)
+				require.NoError(t, err)
+				require.NoError(t, c.TouchAPIKey(ctx, "hash", time.Now()))
+			},
+			expectedKey: revoked,
+			expectedTTL: expiration,
+		},
+		{
+			name: "usage drops the active key",
+			setup: func(t *testing.T, r *mocks, c cacheRepo) {
+				used := time.Now()
+				touched := active
+				touched.LastUsedAt = &used
+				gomock.InOrder(
+					r.repo.EXPECT().GetAPIKeyByHash(gomock.Any(), "hash").Return(active, nil),
+					r.repo.EXPECT().TouchAPIKey(gomock.Any(), "hash", used).Return(nil),
+					r.repo.EXPECT().GetAPIKeyByHash(gomock.Any(), "hash").Return(active, nil),
+				)
+				_, err := c.GetAPIKeyByHash(ctx, "hash")
+				require.NoError(t, err)
+				require.NoError(t, c.TouchAPIKey(ctx, "hash", used))
+			},
+			expectedKey: active,
+			expectedTTL: expiration,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mr := miniredis.RunT(t)
+			r := &mocks{repo: cache.NewMockrepository(ctrl), logger: cache.NewMocklogger(ctrl)}
+			c := cache.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), r.repo, expiration, missExpiration, metrics.New(), r.logger)
+			test.setup(t, r, c)
+
+			key, err := c.GetAPIKeyByHash(ctx, "hash")
+
+			assert.ErrorIs(t, err, test.expectedErr, "Test case %s failed: Error not as expected", test.name)
+			assert.Equal(t, test.expectedKey, key, "Test case %s failed: Key not as expected", test.name)
+			assert.Equal(t, test.expectedTTL, mr.TTL("ak:hash"), "Test case %s failed: TTL not as expected", test.name)
+		})
+	}
+}
+
+type mocks struct {
+	repo   *cache.Mockrepository
+	logger *cache.Mocklogger
+}
+
+type cacheRepo interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) (entity.APIKey, error)
+	TouchAPIKey(ctx context.Context, hash string, usedAt time.Time) error
+}
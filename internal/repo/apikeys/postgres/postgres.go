@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/repoerrs"
+	"time"
+)
+
+// apiKeyColumns is the column list every api key query selects, in the order scanAPIKey reads it.
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+type repo struct {
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
+}
+
+func New(db *pgxpool.Pool, queryTimeout time.Duration) *repo {
+	return &repo{
+		pool:         db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (r *repo) CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var created entity.APIKey
+	row := r.pool.QueryRow(ctx,
+		`INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING `+apiKeyColumns, key.Name, key.Prefix, key.Hash, key.Scopes, key.ExpiresAt)
+	if err := scanAPIKey(row, &created); err != nil {
+		return entity.APIKey{}, fmt.Errorf("apiKeyRepo - CreateAPIKey - row.Scan: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetAPIKeys returns all keys including the revoked and expired ones, newest first.
+func (r *repo) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+apiKeyColumns+`
+             FROM api_keys
+             ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("apiKeyRepo - GetAPIKeys - r.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []entity.APIKey{}
+
+	for rows.Next() {
+		var key entity.APIKey
+
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("apiKeyRepo - GetAPIKeys - rows.Scan: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("apiKeyRepo - GetAPIKeys - rows.Err: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *repo) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var key entity.APIKey
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+`
+			FROM api_keys
+			WHERE key_hash = $1`, hash)
+	if err := scanAPIKey(row, &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, repoerrs.ErrNotFound
+		}
+		return entity.APIKey{}, fmt.Errorf("apiKeyRepo - GetAPIKeyByHash - row.Scan: %w", err)
+	}
+
+	return key, nil
+}
+
+// RevokeAPIKey marks an active key as revoked and returns it, revoked keys are reported as not found.
+func (r *repo) RevokeAPIKey(ctx context.Context, id int) (entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var key entity.APIKey
+	row := r.pool.QueryRow(ctx,
+		`UPDATE api_keys
+			SET revoked_at = NOW()
+			WHERE id = $1 AND revoked_at IS NULL
+			RETURNING `+apiKeyColumns, id)
+	if err := scanAPIKey(row, &key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, repoerrs.ErrNotFound
+		}
+		return entity.APIKey{}, fmt.Errorf("apiKeyRepo - RevokeAPIKey - row.Scan: %w", err)
+	}
+
+	return key, nil
+}
+
+// TouchAPIKey records when the key with the given hash was last used.
+func (r *repo) TouchAPIKey(ctx context.Context, hash string, usedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE key_hash = $1`, hash, usedAt)
+	if err != nil {
+		return fmt.Errorf("apiKeyRepo - TouchAPIKey - r.pool.Exec: %w", err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row, key *entity.APIKey) error {
+	return row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &key.Scopes, &key.CreatedAt,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+}
@@ -0,0 +1,5 @@
+package repoerrs
+
+import "github.com/khasmag06/effective-mobile-test/internal/apperr"
+
+var ErrNotFound = apperr.New(apperr.KindNotFound, "api_key_not_found", "api key not found")
@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/khasmag06/effective-mobile-test/internal/apperr"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrKeyReused         = apperr.New(apperr.KindValidation, "idempotency_key_reused", "idempotency key was already used with a different request")
+	ErrRequestInProgress = apperr.New(apperr.KindConflict, "idempotency_request_in_progress", "a request with this idempotency key is still in progress")
+)
+
+// pendingExpiration limits how long an unfinished request holds its key, so that a crash
+// in the middle of a request does not block the retries for the whole expiration.
+const pendingExpiration = time.Minute
+
+// FinishTimeout bounds Complete and Abort. They are run even after the request is canceled,
+// otherwise the key would answer as in progress until pendingExpiration.
+const FinishTimeout = 5 * time.Second
+
+// maxClaimAttempts bounds the retries of a claim whose key keeps expiring between SetNX and Get.
+const maxClaimAttempts = 3
+
+type store struct {
+	redis      *redis.Client
+	expiration time.Duration
+}
+
+func New(rdb *redis.Client, expiration time.Duration) *store {
+	return &store{
+		redis:      rdb,
+		expiration: expiration,
+	}
+}
+
+// Begin claims the key for the request with the given hash. It returns nil when the request should
+// be executed, or the response remembered from the first request that used the key.
+func (s *store) Begin(ctx context.Context, key, requestHash string) (*entity.IdempotentResponse, error) {
+	pendingJSON, err := json.Marshal(entity.IdempotentResponse{RequestHash: requestHash})
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		claimed, err := s.redis.SetNX(ctx, idempotencyKey(key), pendingJSON, pendingExpiration).Result()
+		if err != nil {
+			return nil, fmt.Errorf("idempotencyStore - Begin - s.redis.SetNX: %w", err)
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		storedJSON, err := s.redis.Get(ctx, idempotencyKey(key)).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				// The key expired right after SetNX failed, it is free to be claimed again.
+				continue
+			}
+			return nil, fmt.Errorf("idempotencyStore - Begin - s.redis.Get: %w", err)
+		}
+		var stored entity.IdempotentResponse
+		if err := json.Unmarshal([]byte(storedJSON), &stored); err != nil {
+			return nil, err
+		}
+
+		if stored.RequestHash != requestHash {
+			return nil, ErrKeyReused
+		}
+		if stored.Status == 0 {
+			return nil, ErrRequestInProgress
+		}
+		return &stored, nil
+	}
+	return nil, ErrRequestInProgress
+}
+
+// Complete remembers the response of the request that claimed the key.
+func (s *store) Complete(ctx context.Context, key string, response entity.IdempotentResponse) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	if err := s.redis.Set(ctx, idempotencyKey(key), responseJSON, s.expiration).Err(); err != nil {
+		return fmt.Errorf("idempotencyStore - Complete - s.redis.Set: %w", err)
+	}
+	return nil
+}
+
+// Abort releases the key of a failed request, so that it can be retried with the same key.
+func (s *store) Abort(ctx context.Context, key string) error {
+	if err := s.redis.Del(ctx, idempotencyKey(key)).Err(); err != nil {
+		return fmt.Errorf("idempotencyStore - Abort - s.redis.Del: %w", err)
+	}
+	return nil
+}
+
+// Key scopes the Idempotency-Key sent by the caller to the operation and to the caller itself,
+// so that different callers using the same key never see each other's responses. The principal
+// is escaped, so that a colon in it cannot make two different pairs of principal and key collide.
+func Key(scope, principal, key string) string {
+	return scope + ":" + url.QueryEscape(principal) + ":" + key
+}
+
+func idempotencyKey(key string) string {
+	return "idem:" + key // idem - idempotency
+}
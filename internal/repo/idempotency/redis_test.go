@@ -0,0 +1,173 @@
+package idempotency_test
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const expiration = 24 * time.Hour
+
+type store interface {
+	Begin(ctx context.Context, key, requestHash string) (*entity.IdempotentResponse, error)
+	Complete(ctx context.Context, key string, response entity.IdempotentResponse) error
+	Abort(ctx context.Context, key string) error
+}
+
+func TestStore_Begin(t *testing.T) {
+	ctx := context.Background()
+	created := entity.IdempotentResponse{
+		RequestHash: "hash-1",
+		Status:      201,
+		ContentType: "application/json",
+		Location:    "/api/v2/people/7",
+		Body:        []byte(`{"id":7}`),
+	}
+
+	tests := []struct {
+		name             string
+		setup            func(t *testing.T, s store, mr *miniredis.Miniredis)
+		requestHash      string
+		expectedResponse *entity.IdempotentResponse
+		expectedErr      error
+		expectedTTL      time.Duration
+	}{
+		{
+			name:        "new key is claimed",
+			requestHash: "hash-1",
+			expectedTTL: time.Minute,
+		},
+		{
+			name: "request in progress",
+			setup: func(t *testing.T, s store, _ *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+			},
+			requestHash: "hash-1",
+			expectedErr: idempotency.ErrRequestInProgress,
+			expectedTTL: time.Minute,
+		},
+		{
+			name: "finished request is replayed",
+			setup: func(t *testing.T, s store, _ *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+				require.NoError(t, s.Complete(ctx, "k", created))
+			},
+			requestHash:      "hash-1",
+			expectedResponse: &created,
+			expectedTTL:      expiration,
+		},
+		{
+			name: "key reused with a different body",
+			setup: func(t *testing.T, s store, _ *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+				require.NoError(t, s.Complete(ctx, "k", created))
+			},
+			requestHash: "hash-2",
+			expectedErr: idempotency.ErrKeyReused,
+			expectedTTL: expiration,
+		},
+		{
+			name: "key reused with a different body while in progress",
+			setup: func(t *testing.T, s store, _ *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+			},
+			requestHash: "hash-2",
+			expectedErr: idempotency.ErrKeyReused,
+			expectedTTL: time.Minute,
+		},
+		{
+			name: "finished request expired",
+			setup: func(t *testing.T, s store, mr *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+				require.NoError(t, s.Complete(ctx, "k", created))
+				mr.FastForward(expiration)
+			},
+			requestHash: "hash-2",
+			expectedTTL: time.Minute,
+		},
+		{
+			name: "abandoned request expired",
+			setup: func(t *testing.T, s store, mr *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+				mr.FastForward(time.Minute)
+			},
+			requestHash: "hash-1",
+			expectedTTL: time.Minute,
+		},
+		{
+			name: "aborted request",
+			setup: func(t *testing.T, s store, _ *miniredis.Miniredis) {
+				_, err := s.Begin(ctx, "k", "hash-1")
+				require.NoError(t, err)
+				require.NoError(t, s.Abort(ctx, "k"))
+			},
+			requestHash: "hash-1",
+			expectedTTL: time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			s := idempotency.New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), expiration)
+			if test.setup != nil {
+				test.setup(t, s, mr)
+			}
+
+			response, err := s.Begin(ctx, "k", test.requestHash)
+
+			assert.ErrorIs(t, err, test.expectedErr, "Test case %s failed: Error not as expected", test.name)
+			assert.Equal(t, test.expectedResponse, response, "Test case %s failed: Response not as expected", test.name)
+			assert.Equal(t, test.expectedTTL, mr.TTL("idem:k"), "Test case %s failed: TTL not as expected", test.name)
+		})
+	}
+}
+
+func TestStore_RedisUnavailable(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	s := idempotency.New(redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1}), expiration)
+	mr.Close()
+
+	_, err := s.Begin(ctx, "k", "hash-1")
+	assert.Error(t, err)
+	assert.Error(t, s.Complete(ctx, "k", entity.IdempotentResponse{RequestHash: "hash-1", Status: 201}))
+	assert.Error(t, s.Abort(ctx, "k"))
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     string
+		principal string
+		key       string
+		expected  string
+	}{
+		{name: "plain principal", scope: "people:create", principal: "user-1", key: "k", expected: "people:create:user-1:k"},
+		{name: "principal with a colon", scope: "people:create", principal: "user:1", key: "k", expected: "people:create:user%3A1:k"},
+		{name: "key with a colon", scope: "people:create", principal: "user", key: "1:k", expected: "people:create:user:1:k"},
+		{name: "anonymous caller", scope: "people:create", key: "k", expected: "people:create::k"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, idempotency.Key(test.scope, test.principal, test.key), "Test case %s failed: Key not as expected", test.name)
+		})
+	}
+
+	// The same key sent by different callers must not be shared
+	assert.NotEqual(t, idempotency.Key("people:create", "user:1", "k"), idempotency.Key("people:create", "user", "1:k"))
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"crypto/sha1"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,73 +14,259 @@ import (
 
 type repo struct {
 	repository
-	redis  *redis.Client
-	logger logger
+	redis   *redis.Client
+	metrics metrics
+	logger  logger
 }
 
-func New(rdb *redis.Client, peopleRepo repository, logger logger) *repo {
+func New(rdb *redis.Client, peopleRepo repository, metrics metrics, logger logger) *repo {
 	return &repo{
 		repository: peopleRepo,
 		redis:      rdb,
+		metrics:    metrics,
 		logger:     logger,
 	}
 }
 
+// Names of the caches in the metrics, the people pages and their counts share the first one.
+const (
+	peopleCacheName = "people"
+	personCacheName = "person"
+	statsCacheName  = "people_stats"
+)
+
 const expiration = 48 * time.Hour // two days
 
-func (r *repo) GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error) {
-	peopleDataCache, err := r.GetPeopleFromCache(ctx, page, limit, sortBy, sortOrder)
+// Key prefixes of the cached people data. The pages, their counts, the cursor pages and the statistics
+// all share peoplePrefix, so that any write invalidates them together with a single pattern.
+const (
+	peoplePrefix  = "p:" // p - people
+	peoplePattern = peoplePrefix + "*"
+)
+
+func (r *repo) GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error) {
+	peopleDataCache, err := r.GetPeopleFromCache(ctx, page, limit, sortBy, sortOrder, filter)
 	if err != nil && !errors.Is(err, redis.Nil) {
-		r.logger.Error(err)
+		r.logger.ErrorContext(ctx, err)
 	}
 	if peopleDataCache != nil {
+		r.metrics.CacheHit(peopleCacheName)
 		return peopleDataCache, nil
 	}
+	r.metrics.CacheMiss(peopleCacheName)
 
-	peopleData, err := r.repository.GetPeople(ctx, page, limit, sortBy, sortOrder)
+	peopleData, err := r.repository.GetPeople(ctx, page, limit, sortBy, sortOrder, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := r.SavePeopleToCache(ctx, page, limit, sortBy, sortOrder, peopleData); err != nil {
-		r.logger.Error(err)
+	if err := r.SavePeopleToCache(ctx, page, limit, sortBy, sortOrder, filter, peopleData); err != nil {
+		r.logger.ErrorContext(ctx, err)
 	}
 	return peopleData, nil
 }
 
-func (r *repo) CreatePerson(ctx context.Context, person entity.Person) error {
-	if err := r.repository.CreatePerson(ctx, person); err != nil {
+func (r *repo) GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error) {
+	pageCache, err := r.GetPeopleCursorPageFromCache(ctx, cursor, limit, sortBy, sortOrder, filter)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.ErrorContext(ctx, err)
+	}
+	if pageCache != nil {
+		r.metrics.CacheHit(peopleCacheName)
+		return *pageCache, nil
+	}
+	r.metrics.CacheMiss(peopleCacheName)
+
+	page, err := r.repository.GetPeopleByCursor(ctx, cursor, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return entity.PeopleCursorPage{}, err
+	}
+
+	if err := r.SavePeopleCursorPageToCache(ctx, cursor, limit, sortBy, sortOrder, filter, page); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return page, nil
+}
+
+func (r *repo) CountPeople(ctx context.Context, filter entity.PeopleFilter) (int, error) {
+	countCache, err := r.GetPeopleCountFromCache(ctx, filter)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.ErrorContext(ctx, err)
+	}
+	if err == nil {
+		r.metrics.CacheHit(peopleCacheName)
+		return countCache, nil
+	}
+	r.metrics.CacheMiss(peopleCacheName)
+
+	count, err := r.repository.CountPeople(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.SavePeopleCountToCache(ctx, filter, count); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return count, nil
+}
+
+func (r *repo) GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error) {
+	statsCache, err := r.GetPeopleStatsFromCache(ctx, query)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.ErrorContext(ctx, err)
+	}
+	if statsCache != nil {
+		r.metrics.CacheHit(statsCacheName)
+		return *statsCache, nil
+	}
+	r.metrics.CacheMiss(statsCacheName)
+
+	stats, err := r.repository.GetPeopleStats(ctx, query)
+	if err != nil {
+		return entity.PeopleStats{}, err
+	}
+
+	if err := r.SavePeopleStatsToCache(ctx, query, stats); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return stats, nil
+}
+
+func (r *repo) GetPersonByID(ctx context.Context, personID int) (entity.Person, error) {
+	personCache, err := r.GetPersonFromCache(ctx, personID)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		r.logger.ErrorContext(ctx, err)
+	}
+	if personCache != nil {
+		r.metrics.CacheHit(personCacheName)
+		return *personCache, nil
+	}
+	r.metrics.CacheMiss(personCacheName)
+
+	person, err := r.repository.GetPersonByID(ctx, personID)
+	if err != nil {
+		return entity.Person{}, err
+	}
+
+	if err := r.SavePersonToCache(ctx, person); err != nil {
+		r.logger.ErrorContext(ctx, err)
+	}
+	return person, nil
+}
+
+// staleKey is the context key of the cache entries made stale by the writes of a transaction.
+type staleKey struct{}
+
+// stale lists the cache entries to invalidate once the transaction is committed.
+type stale struct {
+	people    bool
+	personIDs []int
+}
+
+// InTransaction runs fn in the transaction of the repository and invalidates the cache only after
+// the commit, so that a read in between can't put the data from before the commit back into the cache.
+func (r *repo) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(staleKey{}).(*stale); ok {
+		return r.repository.InTransaction(ctx, fn)
+	}
+
+	entries := &stale{}
+	if err := r.repository.InTransaction(context.WithValue(ctx, staleKey{}, entries), fn); err != nil {
 		return err
 	}
-	if err := r.DeletePeopleFromCache(ctx); err != nil {
-		r.logger.Error(err)
+	if entries.people {
+		r.invalidate(ctx, entries.personIDs...)
 	}
 	return nil
 }
 
-func (r *repo) UpdatePersonData(ctx context.Context, personID int, person entity.Person) error {
-	if err := r.repository.UpdatePersonData(ctx, personID, person); err != nil {
-		return err
+func (r *repo) CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error) {
+	created, err := r.repository.CreatePerson(ctx, person)
+	if err != nil {
+		return entity.Person{}, err
 	}
-	if err := r.DeletePeopleFromCache(ctx); err != nil {
-		r.logger.Error(err)
+	r.invalidate(ctx)
+	return created, nil
+}
+
+func (r *repo) CreatePeople(ctx context.Context, people []entity.Person) ([]int, error) {
+	ids, err := r.repository.CreatePeople(ctx, people)
+	if err != nil {
+		return nil, err
 	}
+	r.invalidate(ctx)
+	return ids, nil
+}
 
-	return nil
+func (r *repo) UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error) {
+	updated, err := r.repository.UpdatePersonData(ctx, personID, person, expectedVersion)
+	if err != nil {
+		return entity.Person{}, err
+	}
+	r.invalidate(ctx, personID)
+	return updated, nil
 }
 
-func (r *repo) DeletePersonData(ctx context.Context, personID int) error {
-	if err := r.repository.DeletePersonData(ctx, personID); err != nil {
-		return err
+func (r *repo) RevertPersonData(ctx context.Context, personID int, person entity.Person) (entity.Person, error) {
+	reverted, err := r.repository.RevertPersonData(ctx, personID, person)
+	if err != nil {
+		return entity.Person{}, err
+	}
+	r.invalidate(ctx, personID)
+	return reverted, nil
+}
+
+func (r *repo) PatchPersonData(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error) {
+	patched, err := r.repository.PatchPersonData(ctx, personID, patch, expectedVersion)
+	if err != nil {
+		return entity.Person{}, err
+	}
+	r.invalidate(ctx, personID)
+	return patched, nil
+}
+
+func (r *repo) DeletePersonData(ctx context.Context, personID int, expectedVersion int) (entity.Person, error) {
+	deleted, err := r.repository.DeletePersonData(ctx, personID, expectedVersion)
+	if err != nil {
+		return entity.Person{}, err
+	}
+	r.invalidate(ctx, personID)
+	return deleted, nil
+}
+
+func (r *repo) RestorePerson(ctx context.Context, personID int) (entity.Person, error) {
+	person, err := r.repository.RestorePerson(ctx, personID)
+	if err != nil {
+		return entity.Person{}, err
 	}
+	r.invalidate(ctx, personID)
+	return person, nil
+}
+
+// invalidate drops the cached people pages and the given people. Within InTransaction it is put off
+// until the commit.
+func (r *repo) invalidate(ctx context.Context, personIDs ...int) {
+	if entries, ok := ctx.Value(staleKey{}).(*stale); ok {
+		entries.people = true
+		entries.personIDs = append(entries.personIDs, personIDs...)
+		return
+	}
+
 	if err := r.DeletePeopleFromCache(ctx); err != nil {
-		r.logger.Error(err)
+		r.logger.ErrorContext(ctx, err)
+	}
+	for _, personID := range personIDs {
+		if err := r.DeletePersonFromCache(ctx, personID); err != nil {
+			r.logger.ErrorContext(ctx, err)
+		}
 	}
-	return nil
 }
 
-func (r *repo) SavePeopleToCache(ctx context.Context, page int, limit int, sortBy, sortOrder string, peopleData []entity.Person) error {
-	key := fmt.Sprintf("p:%d:%d:%s:%s", page, limit, sortBy, string(sortOrder[0])) // p - people
+func (r *repo) SavePeopleToCache(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter, peopleData []entity.Person) error {
+	key, err := peopleKey(page, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return err
+	}
 	peopleJSON, err := json.Marshal(peopleData)
 	if err != nil {
 		return err
@@ -90,8 +277,11 @@ func (r *repo) SavePeopleToCache(ctx context.Context, page int, limit int, sortB
 	return nil
 }
 
-func (r *repo) GetPeopleFromCache(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error) {
-	key := fmt.Sprintf("p:%d:%d:%s:%s", page, limit, sortBy, string(sortOrder[0])) // p - people
+func (r *repo) GetPeopleFromCache(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error) {
+	key, err := peopleKey(page, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return nil, err
+	}
 	peopleJSON, err := r.redis.Get(ctx, key).Result()
 	if err != nil {
 		return nil, err
@@ -104,9 +294,134 @@ func (r *repo) GetPeopleFromCache(ctx context.Context, page int, limit int, sort
 	return peopleDataCache, nil
 }
 
+// peopleKey builds the cache key of a people page. The filter is hashed to keep the key short.
+func peopleKey(page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (string, error) {
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+	filterHash := sha1.Sum(filterJSON)
+	return fmt.Sprintf(peoplePrefix+"%d:%d:%s:%s:%x", page, limit, sortBy, string(sortOrder[0]), filterHash), nil
+}
+
+// peopleCountKey builds the cache key of the number of people matching the filter.
+func peopleCountKey(filter entity.PeopleFilter) (string, error) {
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(peoplePrefix+"n:%x", sha1.Sum(filterJSON)), nil // n - number
+}
+
+func (r *repo) SavePeopleCountToCache(ctx context.Context, filter entity.PeopleFilter, count int) error {
+	key, err := peopleCountKey(filter)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.Set(ctx, key, count, expiration).Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repo) GetPeopleCountFromCache(ctx context.Context, filter entity.PeopleFilter) (int, error) {
+	key, err := peopleCountKey(filter)
+	if err != nil {
+		return 0, err
+	}
+	return r.redis.Get(ctx, key).Int()
+}
+
+// peopleCursorKey builds the cache key of a people page in cursor mode.
+func peopleCursorKey(cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (string, error) {
+	paramsJSON, err := json.Marshal(struct {
+		Cursor    string
+		Limit     int
+		SortBy    string
+		SortOrder string
+		Filter    entity.PeopleFilter
+	}{cursor, limit, sortBy, sortOrder, filter})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(peoplePrefix+"c:%x", sha1.Sum(paramsJSON)), nil // c - cursor
+}
+
+func (r *repo) SavePeopleCursorPageToCache(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter, page entity.PeopleCursorPage) error {
+	key, err := peopleCursorKey(cursor, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return err
+	}
+	pageJSON, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.Set(ctx, key, pageJSON, expiration).Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repo) GetPeopleCursorPageFromCache(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (*entity.PeopleCursorPage, error) {
+	key, err := peopleCursorKey(cursor, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return nil, err
+	}
+	pageJSON, err := r.redis.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var pageCache entity.PeopleCursorPage
+	if err := json.Unmarshal([]byte(pageJSON), &pageCache); err != nil {
+		return nil, err
+	}
+	return &pageCache, nil
+}
+
+// peopleStatsKey builds the cache key of the statistics.
+func peopleStatsKey(query entity.PeopleStatsQuery) (string, error) {
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(peoplePrefix+"s:%x", sha1.Sum(queryJSON)), nil // s - statistics
+}
+
+func (r *repo) SavePeopleStatsToCache(ctx context.Context, query entity.PeopleStatsQuery, stats entity.PeopleStats) error {
+	key, err := peopleStatsKey(query)
+	if err != nil {
+		return err
+	}
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.Set(ctx, key, statsJSON, expiration).Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repo) GetPeopleStatsFromCache(ctx context.Context, query entity.PeopleStatsQuery) (*entity.PeopleStats, error) {
+	key, err := peopleStatsKey(query)
+	if err != nil {
+		return nil, err
+	}
+	statsJSON, err := r.redis.Get(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var statsCache entity.PeopleStats
+	if err := json.Unmarshal([]byte(statsJSON), &statsCache); err != nil {
+		return nil, err
+	}
+	return &statsCache, nil
+}
+
 func (r *repo) DeletePeopleFromCache(ctx context.Context) error {
-	pattern := "p:*" // p - people
-	keysToDelete, err := r.redis.Keys(ctx, pattern).Result()
+	keysToDelete, err := r.redis.Keys(ctx, peoplePattern).Result()
 	if err != nil {
 		return err
 	}
@@ -116,6 +431,43 @@ func (r *repo) DeletePeopleFromCache(ctx context.Context) error {
 			return err
 		}
 	}
+	r.metrics.CacheInvalidated(peopleCacheName)
 
 	return nil
 }
+
+func personKey(personID int) string {
+	return fmt.Sprintf("ps:%d", personID) // ps - person
+}
+
+func (r *repo) SavePersonToCache(ctx context.Context, person entity.Person) error {
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return err
+	}
+	if err := r.redis.Set(ctx, personKey(person.ID), personJSON, expiration).Err(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repo) GetPersonFromCache(ctx context.Context, personID int) (*entity.Person, error) {
+	personJSON, err := r.redis.Get(ctx, personKey(personID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var personCache entity.Person
+	if err := json.Unmarshal([]byte(personJSON), &personCache); err != nil {
+		return nil, err
+	}
+	return &personCache, nil
+}
+
+func (r *repo) DeletePersonFromCache(ctx context.Context, personID int) error {
+	if err := r.redis.Del(ctx, personKey(personID)).Err(); err != nil {
+		return err
+	}
+	r.metrics.CacheInvalidated(personCacheName)
+	return nil
+}
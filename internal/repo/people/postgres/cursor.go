@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// sortColumnTypes maps sort columns to the types their cursor values are cast to.
+var sortColumnTypes = map[string]string{
+	dateSortType:        "timestamptz",
+	nationalitySortType: "text",
+	ageSortType:         "int",
+	genderSortType:      "text",
+}
+
+// nullSortValues stand in for NULL in the keyset comparison, the rows with NULL are already
+// set apart by the flag that precedes the value in the keyset.
+var nullSortValues = map[string]string{
+	dateSortType:        "'-infinity'::timestamptz",
+	nationalitySortType: "''",
+	ageSortType:         "0",
+	genderSortType:      "''",
+}
+
+// sortNames are the sortBy values of the sort columns, the cursors carry them instead of what
+// the client asked for, so that only known orderings come back.
+var sortNames = map[string]string{
+	dateSortType:        "date",
+	nationalitySortType: "nationality",
+	ageSortType:         "age",
+	genderSortType:      "gender",
+}
+
+// cursor points at a row of the (sort field, id) keyset. It is handed out to clients as an opaque string.
+// A nil value is a row whose sort field is NULL.
+type cursor struct {
+	SortBy    string  `json:"s"`
+	SortOrder string  `json:"o"`
+	Value     *string `json:"v"`
+	ID        int     `json:"id"`
+	Backward  bool    `json:"b,omitempty"`
+}
+
+func (c cursor) encode() string {
+	cursorJSON, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(cursorJSON)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	cursorJSON, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, repoerrs.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(cursorJSON, &c); err != nil {
+		return cursor{}, repoerrs.ErrInvalidCursor
+	}
+	if err := c.validate(); err != nil {
+		return cursor{}, err
+	}
+	return c, nil
+}
+
+// validate checks a cursor that came back from a client, it may have been tampered with.
+// The value has to be readable as the type of the sort column before it reaches the query.
+func (c cursor) validate() error {
+	sortField := sortColumn(c.SortBy)
+	if sortNames[sortField] != c.SortBy || (c.SortOrder != "asc" && c.SortOrder != "desc") || c.ID <= 0 {
+		return repoerrs.ErrInvalidCursor
+	}
+	if c.Value == nil {
+		return nil
+	}
+
+	value := *c.Value
+	switch sortColumnTypes[sortField] {
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
+			return repoerrs.ErrInvalidCursor
+		}
+	case "timestamptz":
+		if !isTimestamp(value) {
+			return repoerrs.ErrInvalidCursor
+		}
+	default:
+		if !utf8.ValidString(value) || strings.ContainsRune(value, 0) {
+			return repoerrs.ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// timestampLayouts are the ISO text forms Postgres gives a timestamptz in, depending on the offset of the zone.
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05.999999Z07",
+	"2006-01-02 15:04:05.999999Z07:00",
+	"2006-01-02 15:04:05.999999Z07:00:00",
+}
+
+func isTimestamp(value string) bool {
+	for _, layout := range timestampLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *repo) GetPeopleByCursor(ctx context.Context, encodedCursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	var current *cursor
+	if encodedCursor != "" {
+		c, err := decodeCursor(encodedCursor)
+		if err != nil {
+			return entity.PeopleCursorPage{}, err
+		}
+		// the cursor keeps the ordering it was issued for
+		sortBy, sortOrder = c.SortBy, c.SortOrder
+		current = &c
+	}
+
+	sortField := sortColumn(sortBy)
+	sortDir := sortDirection(sortOrder)
+	sortBy, sortOrder = sortNames[sortField], strings.ToLower(sortDir)
+	backward := current != nil && current.Backward
+
+	// walking backwards is the same query in the reverse order, the result is flipped afterwards
+	queryDir := sortDir
+	if backward {
+		queryDir = reverseDirection(sortDir)
+	}
+
+	conditions, args := buildFilter(filter)
+	if current != nil {
+		operator := ">"
+		if queryDir == sortDescending {
+			operator = "<"
+		}
+		args = append(args, current.Value, current.ID)
+		value := fmt.Sprintf("%s::text::%s", placeholder(len(args)-1), sortColumnTypes[sortField])
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)",
+			sortKey(sortField, sortField, sortDir), operator, sortKey(sortField, value, sortDir), placeholder(len(args))))
+	}
+	// one extra row tells whether there is a page beyond this one
+	args = append(args, limit+1)
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+personColumns+`, `+sortField+`::text
+             FROM people
+             `+whereClause(conditions)+`
+             ORDER BY `+fmt.Sprintf("%s, id %s", sortKeyOrder(sortField, sortDir, queryDir), queryDir)+`
+             LIMIT `+placeholder(len(args)), args...)
+	if err != nil {
+		return entity.PeopleCursorPage{}, fmt.Errorf("personRepo - GetPeopleByCursor - r.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		people     []entity.Person
+		sortValues []*string
+	)
+	for rows.Next() {
+		var (
+			person    entity.Person
+			sortValue *string
+		)
+		err := scanPerson(rows, &person, &sortValue)
+		if err != nil {
+			return entity.PeopleCursorPage{}, fmt.Errorf("personRepo - GetPeopleByCursor - rows.Scan: %w", err)
+		}
+		people = append(people, person)
+		sortValues = append(sortValues, sortValue)
+	}
+	if err := rows.Err(); err != nil {
+		return entity.PeopleCursorPage{}, fmt.Errorf("personRepo - GetPeopleByCursor - rows.Err: %w", err)
+	}
+
+	hasMore := len(people) > limit
+	if hasMore {
+		people, sortValues = people[:limit], sortValues[:limit]
+	}
+	if backward {
+		reverse(people)
+		reverse(sortValues)
+	}
+
+	page := entity.PeopleCursorPage{People: people}
+	if len(people) == 0 {
+		return page, nil
+	}
+
+	hasNext, hasPrev := hasMore, current != nil
+	if backward {
+		hasNext, hasPrev = true, hasMore
+	}
+	last, first := len(people)-1, 0
+	if hasNext {
+		page.NextCursor = cursor{SortBy: sortBy, SortOrder: sortOrder, Value: sortValues[last], ID: people[last].ID}.encode()
+	}
+	if hasPrev {
+		page.PrevCursor = cursor{SortBy: sortBy, SortOrder: sortOrder, Value: sortValues[first], ID: people[first].ID, Backward: true}.encode()
+	}
+
+	return page, nil
+}
+
+// sortKey is the part of the keyset that stands for the sort field, with value being the field itself
+// or a cursor value of it. NULL values go last in both directions: the leading flag orders them
+// after the others, and the NULL itself is replaced, since it would make the whole comparison NULL.
+func sortKey(sortField, value, sortDir string) string {
+	return fmt.Sprintf("%s, COALESCE(%s, %s)", nullFlag(value, sortDir), value, nullSortValues[sortField])
+}
+
+// sortKeyOrder is the ORDER BY of the sortKey of the field, sortDir is the order of the page
+// and queryDir is the order the query walks in.
+func sortKeyOrder(sortField, sortDir, queryDir string) string {
+	return fmt.Sprintf("%s %s, COALESCE(%s, %s) %s", nullFlag(sortField, sortDir), queryDir, sortField, nullSortValues[sortField], queryDir)
+}
+
+// nullFlag orders the NULL values after the others in the given sort direction.
+func nullFlag(value, sortDir string) string {
+	if sortDir == sortDescending {
+		return fmt.Sprintf("(%s) IS NOT NULL", value)
+	}
+	return fmt.Sprintf("(%s) IS NULL", value)
+}
+
+func reverseDirection(sortDir string) string {
+	if sortDir == sortDescending {
+		return sortAscending
+	}
+	return sortDescending
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,45 @@
+package postgres_test
+
+import (
+	"encoding/base64"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/postgres"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	encode := func(cursorJSON string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(cursorJSON))
+	}
+
+	tests := []struct {
+		name        string
+		cursor      string
+		expectedErr error
+	}{
+		{name: "date", cursor: encode(`{"s":"date","o":"asc","v":"2023-10-01 12:00:00.123456+00","id":7}`)},
+		{name: "date without fractional seconds", cursor: encode(`{"s":"date","o":"desc","v":"2023-10-01 12:00:00+05:30","id":7}`)},
+		{name: "age", cursor: encode(`{"s":"age","o":"asc","v":"30","id":7}`)},
+		{name: "nationality", cursor: encode(`{"s":"nationality","o":"desc","v":"RU","id":7,"b":true}`)},
+		{name: "null value", cursor: encode(`{"s":"gender","o":"asc","v":null,"id":7}`)},
+		{name: "not base64", cursor: "not a cursor!", expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "not json", cursor: encode(`date,asc,30,7`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "age that is not a number", cursor: encode(`{"s":"age","o":"asc","v":"thirty","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "age out of range", cursor: encode(`{"s":"age","o":"asc","v":"99999999999","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "date that is not a timestamp", cursor: encode(`{"s":"date","o":"asc","v":"yesterday","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "value with a nul byte", cursor: encode(`{"s":"gender","o":"asc","v":"ma\u0000le","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "unknown sort field", cursor: encode(`{"s":"surname","o":"asc","v":"Ivanov","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "sort field as column", cursor: encode(`{"s":"created_at","o":"asc","v":"2023-10-01 12:00:00+00","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "unknown sort order", cursor: encode(`{"s":"age","o":"asc; DROP TABLE people","v":"30","id":7}`), expectedErr: repoerrs.ErrInvalidCursor},
+		{name: "missing id", cursor: encode(`{"s":"age","o":"asc","v":"30"}`), expectedErr: repoerrs.ErrInvalidCursor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := postgres.DecodeCursor(test.cursor)
+
+			assert.ErrorIs(t, err, test.expectedErr, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
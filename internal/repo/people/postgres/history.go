@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+)
+
+const revisionColumns = "person_id, revision, operation, actor, source, changed_at, before, after"
+
+// AddPersonRevisions records the changes in the history. Called within the transaction of InTransaction
+// that made the changes, a change is never stored without its revision or the other way around.
+func (r *repo) AddPersonRevisions(ctx context.Context, revisions []entity.PersonRevision) error {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var (
+		personIDs  = make([]int, 0, len(revisions))
+		numbers    = make([]int, 0, len(revisions))
+		operations = make([]string, 0, len(revisions))
+		actors     = make([]string, 0, len(revisions))
+		sources    = make([]string, 0, len(revisions))
+		befores    = make([]*string, 0, len(revisions))
+		afters     = make([]string, 0, len(revisions))
+	)
+	for _, revision := range revisions {
+		var before *string
+		if revision.Before != nil {
+			snapshot, err := json.Marshal(revision.Before)
+			if err != nil {
+				return fmt.Errorf("personRepo - AddPersonRevisions - json.Marshal: %w", err)
+			}
+			before = new(string)
+			*before = string(snapshot)
+		}
+		after, err := json.Marshal(revision.After)
+		if err != nil {
+			return fmt.Errorf("personRepo - AddPersonRevisions - json.Marshal: %w", err)
+		}
+
+		personIDs = append(personIDs, revision.PersonID)
+		numbers = append(numbers, revision.Revision)
+		operations = append(operations, revision.Operation)
+		actors = append(actors, revision.Name)
+		sources = append(sources, revision.Source)
+		befores = append(befores, before)
+		afters = append(afters, string(after))
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("personRepo - AddPersonRevisions - r.begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO people_history (person_id, revision, operation, actor, source, before, after)
+			SELECT person_id, revision, operation, actor, source, before::jsonb, after::jsonb
+			FROM UNNEST($1::int[], $2::int[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[])
+				AS revisions(person_id, revision, operation, actor, source, before, after)`,
+		personIDs, numbers, operations, actors, sources, befores, afters)
+	if err != nil {
+		return fmt.Errorf("personRepo - AddPersonRevisions - tx.Exec: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("personRepo - AddPersonRevisions - tx.Commit: %w", err)
+	}
+	return nil
+}
+
+// AddCreationRevisions records the creation of the people on behalf of the actor in ctx with a single
+// statement, the after snapshots are taken from the stored rows.
+func (r *repo) AddCreationRevisions(ctx context.Context, personIDs []int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("personRepo - AddCreationRevisions - r.begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	actor := entity.ActorFromContext(ctx)
+	_, err = tx.Exec(ctx,
+		`INSERT INTO people_history (person_id, revision, operation, actor, source, after)
+			SELECT id, version, $2, $3, $4, jsonb_strip_nulls(jsonb_build_object(
+				'id', id, 'name', name, 'surname', surname, 'patronymic', NULLIF(patronymic, ''), 'age', age,
+				'gender', gender, 'nationality', nationality, 'version', version,
+				'createdAt', created_at, 'updatedAt', updated_at, 'deletedAt', deleted_at))
+			FROM people
+			WHERE id = ANY($1)`, personIDs, entity.OperationCreate, actor.Name, actor.Source)
+	if err != nil {
+		return fmt.Errorf("personRepo - AddCreationRevisions - tx.Exec: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("personRepo - AddCreationRevisions - tx.Commit: %w", err)
+	}
+	return nil
+}
+
+// GetPersonHistory returns the recorded changes of the person, newest first.
+func (r *repo) GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+revisionColumns+`
+             FROM people_history
+             WHERE person_id = $1
+             ORDER BY revision DESC`, personID)
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPersonHistory - r.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var history []entity.PersonRevision
+
+	for rows.Next() {
+		var revision entity.PersonRevision
+
+		err := scanRevision(rows, &revision)
+		if err != nil {
+			return nil, fmt.Errorf("personRepo - GetPersonHistory - rows.Scan: %w", err)
+		}
+
+		history = append(history, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("personRepo - GetPersonHistory - rows.Err: %w", err)
+	}
+
+	return history, nil
+}
+
+func (r *repo) GetPersonRevision(ctx context.Context, personID int, revisionNumber int) (entity.PersonRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var revision entity.PersonRevision
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+revisionColumns+`
+			FROM people_history
+			WHERE person_id = $1 AND revision = $2`, personID, revisionNumber)
+	if err := scanRevision(row, &revision); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.PersonRevision{}, repoerrs.ErrRevisionNotFound
+		}
+		return entity.PersonRevision{}, fmt.Errorf("personRepo - GetPersonRevision - row.Scan: %w", err)
+	}
+
+	return revision, nil
+}
+
+// scanRevision reads the revisionColumns of a row into the revision, decoding the JSON snapshots.
+func scanRevision(row pgx.Row, revision *entity.PersonRevision) error {
+	var before, after []byte
+	err := row.Scan(&revision.PersonID, &revision.Revision, &revision.Operation, &revision.Name, &revision.Source,
+		&revision.ChangedAt, &before, &after)
+	if err != nil {
+		return err
+	}
+
+	if before != nil {
+		revision.Before = &entity.Person{}
+		if err := json.Unmarshal(before, revision.Before); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(after, &revision.After)
+}
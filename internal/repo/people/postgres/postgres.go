@@ -2,13 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"strings"
+	"time"
 )
 
 const (
-	maxPaginationLimit         = 10
+	maxPaginationLimit         = entity.MaxPaginationLimit
 	dateSortType        string = "created_at"
 	nationalitySortType string = "nationality"
 	ageSortType         string = "age"
@@ -18,84 +23,329 @@ const (
 	sortDescending string = "DESC"
 )
 
+// personColumns is the column list every person query selects, in the order scanPerson reads it.
+const personColumns = "id, name, surname, patronymic, age, gender, nationality, version, created_at, updated_at, deleted_at"
+
 type repo struct {
-	pool *pgxpool.Pool
+	pool         *pgxpool.Pool
+	queryTimeout time.Duration
 }
 
-func New(db *pgxpool.Pool) *repo {
+// New creates the people repository. Every method except the streaming export gives up
+// after queryTimeout, the export is bounded by the deadline of its context.
+func New(db *pgxpool.Pool, queryTimeout time.Duration) *repo {
 	return &repo{
-		pool: db,
+		pool:         db,
+		queryTimeout: queryTimeout,
 	}
 }
 
-func (r *repo) CreatePerson(ctx context.Context, person entity.Person) error {
-	_, err := r.pool.Exec(ctx,
+// CreatePerson inserts the person and returns it as stored, with the assigned id and version.
+func (r *repo) CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - CreatePerson - r.begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var created entity.Person
+	row := tx.QueryRow(ctx,
 		`INSERT INTO people (name, surname, patronymic, age, gender, nationality)
-			VALUES ($1, $2, $3, $4, $5, $6)`, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING `+personColumns, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality)
+	if err := scanPerson(row, &created); err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - CreatePerson - row.Scan: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - CreatePerson - tx.Commit: %w", err)
+	}
+	return created, nil
+}
+
+// CreatePeople copies the people into the table with CopyFrom and returns the ids assigned to them,
+// in the order of the input. COPY can't return the rows it inserts, so the ids are taken from the
+// sequence of the table beforehand.
+func (r *repo) CreatePeople(ctx context.Context, people []entity.Person) ([]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("personRepo - CreatePerson - r.pool.Exec: %v", err)
+		return nil, fmt.Errorf("personRepo - CreatePeople - r.begin: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	return nil
+	rows, err := tx.Query(ctx,
+		`SELECT nextval(pg_get_serial_sequence('people', 'id'))
+			FROM generate_series(1, $1)`, len(people))
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - CreatePeople - tx.Query: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - CreatePeople - rows.Scan: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"people"},
+		[]string{"id", "name", "surname", "patronymic", "age", "gender", "nationality"},
+		pgx.CopyFromSlice(len(people), func(i int) ([]any, error) {
+			person := people[i]
+			return []any{ids[i], person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality}, nil
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - CreatePeople - tx.CopyFrom: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("personRepo - CreatePeople - tx.Commit: %w", err)
+	}
+	return ids, nil
+}
+
+// UpdatePersonData overwrites the person and returns it as stored. A non-zero expectedVersion makes
+// the update conditional on the stored version, every successful update increments it.
+func (r *repo) UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error) {
+	return r.overwritePerson(ctx, "UpdatePersonData", personID, person, expectedVersion)
+}
+
+// RevertPersonData overwrites the person with the data of an earlier revision.
+func (r *repo) RevertPersonData(ctx context.Context, personID int, person entity.Person) (entity.Person, error) {
+	return r.overwritePerson(ctx, "RevertPersonData", personID, person, 0)
+}
+
+func (r *repo) overwritePerson(ctx context.Context, method string, personID int, person entity.Person, expectedVersion int) (entity.Person, error) {
+	return r.changePerson(ctx, method, personID, expectedVersion, false,
+		`UPDATE people
+			SET name = $1, surname = $2, patronymic = $3, age = $4, gender = $5, nationality = $6, version = version + 1
+			WHERE id = $7
+			RETURNING `+personColumns,
+		person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality, personID)
 }
 
-func (r *repo) UpdatePersonData(ctx context.Context, personID int, person entity.Person) error {
-	_, err := r.pool.Exec(ctx,
-		`UPDATE people 
-			SET name = $1, surname = $2, patronymic = $3, age = $4, gender = $5, nationality = $6
-			WHERE id = $7`, person.Name, person.Surname, person.Patronymic, person.Age, person.Gender, person.Nationality, personID)
+// PatchPersonData writes the fields set in the patch and returns the person as stored.
+func (r *repo) PatchPersonData(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error) {
+	var (
+		assignments []string
+		args        []any
+	)
+	set := func(column string, value any) {
+		args = append(args, value)
+		assignments = append(assignments, fmt.Sprintf("%s = %s", column, placeholder(len(args))))
+	}
+
+	if patch.Name != nil {
+		set("name", *patch.Name)
+	}
+	if patch.Surname != nil {
+		set("surname", *patch.Surname)
+	}
+	if patch.Patronymic != nil {
+		set("patronymic", *patch.Patronymic)
+	}
+	if patch.Age != nil {
+		set("age", *patch.Age)
+	}
+	if patch.Gender != nil {
+		set("gender", *patch.Gender)
+	}
+	if patch.Nationality != nil {
+		set("nationality", *patch.Nationality)
+	}
+	if len(assignments) == 0 {
+		// nothing to write, but a stale version is still reported like for any other patch
+		person, err := r.GetPersonByID(ctx, personID)
+		if err != nil {
+			return entity.Person{}, err
+		}
+		if expectedVersion != 0 && expectedVersion != person.Version {
+			return entity.Person{}, repoerrs.ErrConflict
+		}
+		return person, nil
+	}
+	assignments = append(assignments, "version = version + 1")
+	args = append(args, personID)
+
+	return r.changePerson(ctx, "PatchPersonData", personID, expectedVersion, false,
+		`UPDATE people
+			SET `+strings.Join(assignments, ", ")+`
+			WHERE id = `+placeholder(len(args))+`
+			RETURNING `+personColumns, args...)
+}
+
+// DeletePersonData moves the person to the trash and returns it as stored. The row stays in the table
+// until it is restored or purged once the retention period has passed.
+func (r *repo) DeletePersonData(ctx context.Context, fioID int, expectedVersion int) (entity.Person, error) {
+	return r.changePerson(ctx, "DeletePersonData", fioID, expectedVersion, false,
+		`UPDATE people
+			SET deleted_at = NOW(), version = version + 1
+			WHERE id = $1
+			RETURNING `+personColumns, fioID)
+}
+
+// GetDeletedPeople returns a page of people in the trash, most recently deleted first.
+func (r *repo) GetDeletedPeople(ctx context.Context, page int, limit int) ([]entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+personColumns+`
+             FROM people
+             WHERE deleted_at IS NOT NULL
+             ORDER BY deleted_at DESC, id DESC
+             LIMIT $1 OFFSET $2`, limit, (page-1)*limit)
 	if err != nil {
-		return fmt.Errorf("personRepo - UpdatePerson - r.pool.Exec: %v", err)
+		return nil, fmt.Errorf("personRepo - GetDeletedPeople - r.pool.Query: %w", err)
 	}
+	defer rows.Close()
 
-	return nil
+	var people []entity.Person
+
+	for rows.Next() {
+		var person entity.Person
+
+		err := scanPerson(rows, &person)
+		if err != nil {
+			return nil, fmt.Errorf("personRepo - GetDeletedPeople - rows.Scan: %w", err)
+		}
+
+		people = append(people, person)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("personRepo - GetDeletedPeople - rows.Err: %w", err)
+	}
+
+	return people, nil
+}
+
+// RestorePerson takes the person out of the trash and returns it as it is stored now.
+func (r *repo) RestorePerson(ctx context.Context, personID int) (entity.Person, error) {
+	return r.changePerson(ctx, "RestorePerson", personID, 0, true,
+		`UPDATE people
+			SET deleted_at = NULL, version = version + 1
+			WHERE id = $1
+			RETURNING `+personColumns, personID)
 }
 
-func (r *repo) DeletePersonData(ctx context.Context, fioID int) error {
-	_, err := r.pool.Exec(ctx,
-		`DELETE 
+// PurgeDeletedPeople permanently removes people that were moved to the trash before the given time.
+func (r *repo) PurgeDeletedPeople(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx,
+		`DELETE
 			FROM people
-			WHERE id = $1`, fioID)
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1`, deletedBefore)
 	if err != nil {
-		return fmt.Errorf("personRepo - DeletePerson - r.pool.Exec: %v", err)
+		return 0, fmt.Errorf("personRepo - PurgeDeletedPeople - r.pool.Exec: %w", err)
 	}
 
-	return nil
+	return tag.RowsAffected(), nil
 }
 
-func (r *repo) GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error) {
-	if limit > maxPaginationLimit {
-		limit = maxPaginationLimit
+// GetPersonForUpdate returns the person, in the trash or not, and locks it until the end of the
+// transaction of InTransaction, so that nothing changes it in between.
+func (r *repo) GetPersonForUpdate(ctx context.Context, personID int) (entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - GetPersonForUpdate - r.begin: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	var sortField string
-	switch sortBy {
-	case "nationality":
-		sortField = nationalitySortType
-	case "gender":
-		sortField = genderSortType
-	case "age":
-		sortField = ageSortType
-	default:
-		sortField = dateSortType
+	person, err := lockPerson(ctx, tx, personID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Person{}, repoerrs.ErrNotFound
+		}
+		return entity.Person{}, fmt.Errorf("personRepo - GetPersonForUpdate - row.Scan: %w", err)
 	}
 
-	var sortDir string
-	switch sortOrder {
-	case "desc":
-		sortDir = sortDescending
-	default:
-		sortDir = sortAscending
+	if err := tx.Commit(ctx); err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - GetPersonForUpdate - tx.Commit: %w", err)
+	}
+	return person, nil
+}
+
+// changePerson locks the person and runs the update query against it. The person must be in the trash
+// or out of it as deleted says, otherwise it is reported as not found. A non-zero expectedVersion must
+// match the stored version.
+func (r *repo) changePerson(ctx context.Context, method string, personID, expectedVersion int, deleted bool, query string, args ...any) (entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - %s - r.begin: %w", method, err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockPerson(ctx, tx, personID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Person{}, repoerrs.ErrNotFound
+		}
+		return entity.Person{}, fmt.Errorf("personRepo - %s - row.Scan: %w", method, err)
+	}
+	if (before.DeletedAt != nil) != deleted {
+		return entity.Person{}, repoerrs.ErrNotFound
+	}
+	if expectedVersion != 0 && expectedVersion != before.Version {
+		return entity.Person{}, repoerrs.ErrConflict
+	}
+
+	var after entity.Person
+	if err := scanPerson(tx.QueryRow(ctx, query, args...), &after); err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - %s - row.Scan: %w", method, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return entity.Person{}, fmt.Errorf("personRepo - %s - tx.Commit: %w", method, err)
+	}
+	return after, nil
+}
+
+func lockPerson(ctx context.Context, tx pgx.Tx, personID int) (entity.Person, error) {
+	var person entity.Person
+	row := tx.QueryRow(ctx,
+		`SELECT `+personColumns+`
+			FROM people
+			WHERE id = $1
+			FOR UPDATE`, personID)
+	err := scanPerson(row, &person)
+	return person, err
+}
+
+func (r *repo) GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if limit > maxPaginationLimit {
+		limit = maxPaginationLimit
 	}
 
 	offset := (page - 1) * limit
-	orderBy := fmt.Sprintf("%s %s", sortField, sortDir)
+	orderBy := fmt.Sprintf("%s %s", sortColumn(sortBy), sortDirection(sortOrder))
+	conditions, args := buildFilter(filter)
+	args = append(args, limit, offset)
 
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, name, surname, patronymic, age, gender, nationality
+		`SELECT `+personColumns+`
              FROM people
+             `+whereClause(conditions)+`
              ORDER BY `+orderBy+`
-             LIMIT $1 OFFSET $2`, limit, offset)
+             LIMIT `+placeholder(len(args)-1)+` OFFSET `+placeholder(len(args)), args...)
 
 	if err != nil {
 		return nil, fmt.Errorf("personRepo - GetPeople - r.pool.Query: %w", err)
@@ -107,7 +357,7 @@ func (r *repo) GetPeople(ctx context.Context, page int, limit int, sortBy, sortO
 	for rows.Next() {
 		var person entity.Person
 
-		err := rows.Scan(&person.ID, &person.Name, &person.Surname, &person.Patronymic, &person.Age, &person.Gender, &person.Nationality)
+		err := scanPerson(rows, &person)
 		if err != nil {
 			return nil, fmt.Errorf("personRepo - GetPeople - rows.Scan: %w", err)
 		}
@@ -122,11 +372,219 @@ func (r *repo) GetPeople(ctx context.Context, page int, limit int, sortBy, sortO
 	return people, nil
 }
 
+// ExportPeople walks over all people matching the filter without a page limit, handing them to fn
+// one by one as they are read from the database.
+func (r *repo) ExportPeople(ctx context.Context, sortBy, sortOrder string, filter entity.PeopleFilter, fn func(entity.Person) error) error {
+	orderBy := fmt.Sprintf("%s %s, id %s", sortColumn(sortBy), sortDirection(sortOrder), sortDirection(sortOrder))
+	conditions, args := buildFilter(filter)
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+personColumns+`
+             FROM people
+             `+whereClause(conditions)+`
+             ORDER BY `+orderBy, args...)
+	if err != nil {
+		return fmt.Errorf("personRepo - ExportPeople - r.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var person entity.Person
+
+		err := scanPerson(rows, &person)
+		if err != nil {
+			return fmt.Errorf("personRepo - ExportPeople - rows.Scan: %w", err)
+		}
+		if err := fn(person); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("personRepo - ExportPeople - rows.Err: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repo) CountPeople(ctx context.Context, filter entity.PeopleFilter) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	conditions, args := buildFilter(filter)
+
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*)
+			FROM people
+			`+whereClause(conditions), args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("personRepo - CountPeople - r.pool.QueryRow: %w", err)
+	}
+
+	return count, nil
+}
+
+// SearchPeople finds people whose full name is similar to the query, tolerating typos and case.
+// Trigram word similarity catches misspellings, the full-text match catches reordered words.
+func (r *repo) SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	if limit > maxPaginationLimit {
+		limit = maxPaginationLimit
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+personColumns+`,
+                word_similarity($1, lower(name || ' ' || surname || ' ' || coalesce(patronymic, ''))) AS score
+             FROM people
+             WHERE deleted_at IS NULL
+                AND ($1 <% lower(name || ' ' || surname || ' ' || coalesce(patronymic, ''))
+                OR to_tsvector('simple', name || ' ' || surname || ' ' || coalesce(patronymic, '')) @@ plainto_tsquery('simple', $1))
+             ORDER BY score DESC, id
+             LIMIT $2`, strings.ToLower(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - SearchPeople - r.pool.Query: %w", err)
+	}
+	defer rows.Close()
+
+	var results []entity.PersonSearchResult
+
+	for rows.Next() {
+		var result entity.PersonSearchResult
+
+		err := scanPerson(rows, &result.Person, &result.Score)
+		if err != nil {
+			return nil, fmt.Errorf("personRepo - SearchPeople - rows.Scan: %w", err)
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("personRepo - SearchPeople - rows.Err: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *repo) GetPersonByID(ctx context.Context, personID int) (entity.Person, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var person entity.Person
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+personColumns+`
+			FROM people
+			WHERE id = $1 AND deleted_at IS NULL`, personID)
+	if err := scanPerson(row, &person); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Person{}, repoerrs.ErrNotFound
+		}
+		return entity.Person{}, fmt.Errorf("personRepo - GetPersonByID - row.Scan: %w", err)
+	}
+
+	return person, nil
+}
+
 func (r *repo) CheckPersonExists(ctx context.Context, personID int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
 	var exists bool
-	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM people WHERE id = $1)`, personID).Scan(&exists)
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM people WHERE id = $1 AND deleted_at IS NULL)`, personID).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("personRepo - CheckPersonExists - r.pool.QueryRow: %v", err)
+		return false, fmt.Errorf("personRepo - CheckPersonExists - r.pool.QueryRow: %w", err)
 	}
 	return exists, nil
 }
+
+func sortColumn(sortBy string) string {
+	switch sortBy {
+	case "nationality":
+		return nationalitySortType
+	case "gender":
+		return genderSortType
+	case "age":
+		return ageSortType
+	default:
+		return dateSortType
+	}
+}
+
+func sortDirection(sortOrder string) string {
+	switch sortOrder {
+	case "desc":
+		return sortDescending
+	default:
+		return sortAscending
+	}
+}
+
+// buildFilter returns the WHERE conditions for the given filter and their positional arguments.
+// People in the trash never match.
+func buildFilter(filter entity.PeopleFilter) ([]string, []any) {
+	var (
+		conditions = []string{"deleted_at IS NULL"}
+		args       []any
+	)
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, placeholder(len(args))))
+	}
+
+	if filter.Name != "" {
+		addCondition("name ILIKE %s", prefixPattern(filter.Name))
+	}
+	if filter.Surname != "" {
+		addCondition("surname ILIKE %s", prefixPattern(filter.Surname))
+	}
+	if filter.Patronymic != "" {
+		addCondition("patronymic ILIKE %s", prefixPattern(filter.Patronymic))
+	}
+	if filter.AgeFrom != nil {
+		addCondition("age >= %s", *filter.AgeFrom)
+	}
+	if filter.AgeTo != nil {
+		addCondition("age <= %s", *filter.AgeTo)
+	}
+	if filter.Gender != "" {
+		addCondition("gender = %s", filter.Gender)
+	}
+	if len(filter.Nationalities) > 0 {
+		addCondition("nationality = ANY(%s)", filter.Nationalities)
+	}
+	if filter.CreatedFrom != nil {
+		addCondition("created_at >= %s", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		addCondition("created_at <= %s", *filter.CreatedTo)
+	}
+
+	return conditions, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+func placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// prefixPattern escapes LIKE wildcards in the value and turns it into a prefix pattern.
+func prefixPattern(value string) string {
+	return likeEscaper.Replace(value) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// scanPerson reads the personColumns of a row into the person, followed by any extra columns.
+func scanPerson(row pgx.Row, person *entity.Person, extra ...any) error {
+	dest := []any{&person.ID, &person.Name, &person.Surname, &person.Patronymic, &person.Age, &person.Gender, &person.Nationality, &person.Version, &person.CreatedAt, &person.UpdatedAt, &person.DeletedAt}
+	return row.Scan(append(dest, extra...)...)
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"slices"
+)
+
+// GetPeopleStats computes the statistics of the people matching the filter. The queries run in
+// a single read-only snapshot, so that the sections of the result add up to the same people.
+func (r *repo) GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return entity.PeopleStats{}, fmt.Errorf("personRepo - GetPeopleStats - r.pool.BeginTx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	conditions, args := buildFilter(query.Filter)
+
+	stats := entity.PeopleStats{}
+	if stats.Genders, err = genderCounts(ctx, tx, conditions, args); err != nil {
+		return entity.PeopleStats{}, err
+	}
+	for _, gender := range stats.Genders {
+		stats.Total += gender.Count
+	}
+	if stats.Nationalities, err = topNationalities(ctx, tx, conditions, args, query.TopNationalities); err != nil {
+		return entity.PeopleStats{}, err
+	}
+	if stats.AgeHistogram, err = ageHistogram(ctx, tx, conditions, args, query.AgeBuckets); err != nil {
+		return entity.PeopleStats{}, err
+	}
+	if stats.Growth, err = growth(ctx, tx, conditions, args, query.GrowthInterval); err != nil {
+		return entity.PeopleStats{}, err
+	}
+
+	return stats, nil
+}
+
+func genderCounts(ctx context.Context, tx pgx.Tx, conditions []string, args []any) ([]entity.GenderCount, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT COALESCE(gender, ''), COUNT(*)
+			FROM people
+			`+whereClause(conditions)+`
+			GROUP BY 1
+			ORDER BY 2 DESC, 1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - genders - tx.Query: %w", err)
+	}
+
+	genders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.GenderCount, error) {
+		var gender entity.GenderCount
+		err := row.Scan(&gender.Gender, &gender.Count)
+		return gender, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - genders - rows.Scan: %w", err)
+	}
+	return genders, nil
+}
+
+// topNationalities returns the most common nationalities with the average and the median age of their people.
+func topNationalities(ctx context.Context, tx pgx.Tx, conditions []string, args []any, top int) ([]entity.NationalityStats, error) {
+	args = append(slices.Clip(args), top)
+	rows, err := tx.Query(ctx,
+		`SELECT COALESCE(nationality, ''), COUNT(*),
+				COALESCE(AVG(age), 0)::float8,
+				COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY age), 0)::float8
+			FROM people
+			`+whereClause(conditions)+`
+			GROUP BY 1
+			ORDER BY 2 DESC, 1
+			LIMIT `+placeholder(len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - nationalities - tx.Query: %w", err)
+	}
+
+	nationalities, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.NationalityStats, error) {
+		var nationality entity.NationalityStats
+		err := row.Scan(&nationality.Nationality, &nationality.Count, &nationality.AverageAge, &nationality.MedianAge)
+		return nationality, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - nationalities - rows.Scan: %w", err)
+	}
+	return nationalities, nil
+}
+
+// ageHistogram counts the people per age bucket, the buckets no one falls into are reported empty.
+func ageHistogram(ctx context.Context, tx pgx.Tx, conditions []string, args []any, bucketStarts []int) ([]entity.AgeBucket, error) {
+	thresholds := append([]int{0}, bucketStarts...)
+	histogram := make([]entity.AgeBucket, len(thresholds))
+	for i := range thresholds {
+		histogram[i].From = thresholds[i]
+		if i+1 < len(thresholds) {
+			histogram[i].To = &thresholds[i+1]
+		}
+	}
+
+	conditions = append(slices.Clip(conditions), "age IS NOT NULL")
+	args = append(slices.Clip(args), thresholds)
+	// WIDTH_BUCKET numbers the buckets from one, the people younger than the first threshold get zero
+	rows, err := tx.Query(ctx,
+		`SELECT WIDTH_BUCKET(age, `+placeholder(len(args))+`::int[]), COUNT(*)
+			FROM people
+			`+whereClause(conditions)+`
+			GROUP BY 1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - age histogram - tx.Query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, fmt.Errorf("personRepo - GetPeopleStats - age histogram - rows.Scan: %w", err)
+		}
+		if bucket > 0 {
+			histogram[bucket-1].Count = count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - age histogram - rows.Err: %w", err)
+	}
+	return histogram, nil
+}
+
+// growth counts the people created per period in UTC, from the period of the first of them to the period
+// of the last one. The periods in between in which no one was created are reported empty.
+func growth(ctx context.Context, tx pgx.Tx, conditions []string, args []any, interval string) ([]entity.GrowthPoint, error) {
+	args = append(slices.Clip(args), interval)
+	intervalArg := placeholder(len(args)) + "::text"
+	rows, err := tx.Query(ctx,
+		`WITH periods AS (
+				SELECT DATE_TRUNC(`+intervalArg+`, created_at AT TIME ZONE 'UTC') AS period, COUNT(*) AS people
+					FROM people
+					`+whereClause(conditions)+`
+					GROUP BY 1
+			)
+			SELECT series.period, COALESCE(periods.people, 0),
+				(SUM(COALESCE(periods.people, 0)) OVER (ORDER BY series.period))::bigint
+			FROM GENERATE_SERIES((SELECT MIN(period) FROM periods), (SELECT MAX(period) FROM periods), ('1 ' || `+intervalArg+`)::interval) AS series(period)
+				LEFT JOIN periods ON periods.period = series.period
+			ORDER BY series.period`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - growth - tx.Query: %w", err)
+	}
+
+	points, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.GrowthPoint, error) {
+		var point entity.GrowthPoint
+		err := row.Scan(&point.Period, &point.Count, &point.Cumulative)
+		return point, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("personRepo - GetPeopleStats - growth - rows.Scan: %w", err)
+	}
+	return points, nil
+}
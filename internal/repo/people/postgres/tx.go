@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+type txKey struct{}
+
+// InTransaction runs fn in one transaction. The repository methods called with the context fn receives
+// take part in it, so their changes are committed together or not at all. A nested call joins the
+// transaction already running.
+func (r *repo) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("personRepo - InTransaction - r.pool.Begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("personRepo - InTransaction - tx.Commit: %w", err)
+	}
+	return nil
+}
+
+// begin starts a transaction of its own, or a savepoint within the transaction of InTransaction
+// when ctx carries one, so that a failed method leaves the outer transaction usable.
+func (r *repo) begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx.Begin(ctx)
+	}
+	return r.pool.Begin(ctx)
+}
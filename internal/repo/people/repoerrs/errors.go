@@ -1,5 +1,10 @@
 package repoerrs
 
-import "fmt"
+import "github.com/khasmag06/effective-mobile-test/internal/apperr"
 
-var ErrNotFound = fmt.Errorf("person not found")
+var (
+	ErrNotFound         = apperr.New(apperr.KindNotFound, "person_not_found", "person not found")
+	ErrInvalidCursor    = apperr.New(apperr.KindValidation, "invalid_cursor", "invalid cursor")
+	ErrConflict         = apperr.New(apperr.KindConflict, "version_conflict", "person version conflict")
+	ErrRevisionNotFound = apperr.New(apperr.KindNotFound, "revision_not_found", "person revision not found")
+)
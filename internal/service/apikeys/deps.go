@@ -0,0 +1,20 @@
+//go:generate mockgen -source=$GOFILE -destination=mocks_test.go -package=$GOPACKAGE
+package apikeys
+
+import (
+	"context"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"time"
+)
+
+type repository interface {
+	CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) (entity.APIKey, error)
+	TouchAPIKey(ctx context.Context, hash string, usedAt time.Time) error
+}
+
+type logger interface {
+	ErrorfContext(ctx context.Context, format string, args ...any)
+}
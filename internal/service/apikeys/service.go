@@ -0,0 +1,125 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/repoerrs"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"strconv"
+	"time"
+)
+
+const (
+	keyPrefix    = "fio_"
+	keySecretLen = 32
+	// displayPrefixLen is how much of the key is kept in plain text to tell the keys apart.
+	displayPrefixLen = len(keyPrefix) + 6
+	// lastUsedPrecision limits the writes of the last usage time to one per key and interval.
+	lastUsedPrecision = time.Minute
+	// subjectPrefix marks the identities of api keys apart from the token subjects. The subject
+	// carries the key id, names are not unique and two keys must never share a caller.
+	subjectPrefix = "apikey:"
+)
+
+type service struct {
+	repo   repository
+	logger logger
+	now    func() time.Time
+	*validator.CustomValidator
+}
+
+func New(r repository, l logger) *service {
+	return &service{
+		repo:            r,
+		logger:          l,
+		now:             time.Now,
+		CustomValidator: validator.NewCustomValidator(),
+	}
+}
+
+// IssueAPIKey creates a key with the requested scopes. The returned key is the only copy of it,
+// only its hash is stored.
+func (s *service) IssueAPIKey(ctx context.Context, req entity.APIKeyRequest) (entity.IssuedAPIKey, error) {
+	if err := s.Validate(req); err != nil {
+		return entity.IssuedAPIKey{}, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return entity.IssuedAPIKey{}, &validator.ValidationError{Fields: []validator.FieldError{
+			{Field: "expiresAt", Message: "field expiresAt must be in the future"},
+		}}
+	}
+
+	secret := make([]byte, keySecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return entity.IssuedAPIKey{}, fmt.Errorf("apiKeyService - IssueAPIKey - rand.Read: %w", err)
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := s.repo.CreateAPIKey(ctx, entity.APIKey{
+		Name:      req.Name,
+		Prefix:    key[:displayPrefixLen],
+		Hash:      hashKey(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return entity.IssuedAPIKey{}, err
+	}
+
+	return entity.IssuedAPIKey{APIKey: created, Key: key}, nil
+}
+
+func (s *service) GetAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	return s.repo.GetAPIKeys(ctx)
+}
+
+func (s *service) RevokeAPIKey(ctx context.Context, id int) error {
+	_, err := s.repo.RevokeAPIKey(ctx, id)
+	return err
+}
+
+// AuthenticateAPIKey returns the identity of an active key. Unknown, revoked and expired keys
+// are rejected with auth.ErrUnauthenticated.
+func (s *service) AuthenticateAPIKey(ctx context.Context, key string) (auth.Identity, error) {
+	hash := hashKey(key)
+	stored, err := s.repo.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return auth.Identity{}, fmt.Errorf("%w: unknown api key", auth.ErrUnauthenticated)
+		}
+		return auth.Identity{}, err
+	}
+
+	now := s.now()
+	switch {
+	case stored.RevokedAt != nil:
+		return auth.Identity{}, fmt.Errorf("%w: api key %s is revoked", auth.ErrUnauthenticated, stored.Prefix)
+	case stored.ExpiresAt != nil && !stored.ExpiresAt.After(now):
+		return auth.Identity{}, fmt.Errorf("%w: api key %s has expired", auth.ErrUnauthenticated, stored.Prefix)
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedPrecision {
+		// The usage time is bookkeeping only, failing to record it does not reject a valid key
+		if err := s.repo.TouchAPIKey(ctx, hash, now); err != nil {
+			s.logger.ErrorfContext(ctx, "failed to record usage of api key %s: %v", stored.Prefix, err)
+		}
+	}
+
+	roles := make([]auth.Role, 0, len(stored.Scopes))
+	for _, scope := range stored.Scopes {
+		roles = append(roles, auth.Role(scope))
+	}
+	return auth.Identity{Subject: subjectPrefix + strconv.Itoa(stored.ID), Roles: roles}, nil
+}
+
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,194 @@
+package apikeys_test
+
+import (
+	"context"
+	"errors"
+	"github.com/khasmag06/effective-mobile-test/internal/auth"
+	"github.com/khasmag06/effective-mobile-test/internal/repo/apikeys/repoerrs"
+	"github.com/khasmag06/effective-mobile-test/internal/service/apikeys"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/khasmag06/effective-mobile-test/internal/entity"
+)
+
+func TestService_IssueAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := apikeys.NewMockrepository(ctrl)
+	mockLogger := apikeys.NewMocklogger(ctrl)
+	svc := apikeys.New(mockRepo, mockLogger)
+
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		req          entity.APIKeyRequest
+		expectCreate bool
+		createErr    error
+		expectedErr  error
+	}{
+		{
+			name:         "valid request",
+			req:          entity.APIKeyRequest{Name: "nightly-import", Scopes: []string{"editor"}},
+			expectCreate: true,
+		},
+		{
+			name: "invalid request",
+			req:  entity.APIKeyRequest{Scopes: []string{"owner"}},
+			expectedErr: &validator.ValidationError{Fields: []validator.FieldError{
+				{Field: "name", Message: "field name is required"},
+				{Field: "scopes[0]", Message: "field scopes[0] must be one of (reader editor admin)"},
+			}},
+		},
+		{
+			name: "expired",
+			req:  entity.APIKeyRequest{Name: "nightly-import", Scopes: []string{"reader"}, ExpiresAt: &past},
+			expectedErr: &validator.ValidationError{Fields: []validator.FieldError{
+				{Field: "expiresAt", Message: "field expiresAt must be in the future"},
+			}},
+		},
+		{
+			name:         "create error",
+			req:          entity.APIKeyRequest{Name: "nightly-import", Scopes: []string{"editor"}},
+			expectCreate: true,
+			createErr:    errors.New("create error"),
+			expectedErr:  errors.New("create error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stored entity.APIKey
+			if test.expectCreate {
+				mockRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, key entity.APIKey) (entity.APIKey, error) {
+						stored = key
+						key.ID = 1
+						return key, test.createErr
+					})
+			}
+
+			issued, err := svc.IssueAPIKey(context.Background(), test.req)
+
+			assert.Equal(t, test.expectedErr, err, "Test case %s failed", test.name)
+			if err == nil {
+				assert.True(t, strings.HasPrefix(issued.Key, stored.Prefix))
+				assert.Len(t, stored.Hash, 64)
+				assert.Equal(t, test.req.Scopes, issued.Scopes)
+			}
+		})
+	}
+}
+
+func TestService_AuthenticateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := apikeys.NewMockrepository(ctrl)
+	mockLogger := apikeys.NewMocklogger(ctrl)
+	svc := apikeys.New(mockRepo, mockLogger)
+
+	past, recently := time.Now().Add(-time.Hour), time.Now().Add(-time.Second)
+	active := entity.APIKey{ID: 1, Name: "nightly-import", Prefix: "fio_abcdef", Scopes: []string{"reader", "editor"}}
+
+	tests := []struct {
+		name         string
+		stored       entity.APIKey
+		getErr       error
+		expectTouch  bool
+		touchErr     error
+		expectLog    bool
+		expected     auth.Identity
+		expectedErr  error
+		unauthorized bool
+	}{
+		{
+			name:        "first use",
+			stored:      active,
+			expectTouch: true,
+			expected:    auth.Identity{Subject: "apikey:1", Roles: []auth.Role{auth.RoleReader, auth.RoleEditor}},
+		},
+		{
+			name: "recently used",
+			stored: func() entity.APIKey {
+				key := active
+				key.LastUsedAt = &recently
+				return key
+			}(),
+			expected: auth.Identity{Subject: "apikey:1", Roles: []auth.Role{auth.RoleReader, auth.RoleEditor}},
+		},
+		{
+			name: "key with the same name",
+			stored: func() entity.APIKey {
+				key := active
+				key.ID = 2
+				key.LastUsedAt = &recently
+				return key
+			}(),
+			expected: auth.Identity{Subject: "apikey:2", Roles: []auth.Role{auth.RoleReader, auth.RoleEditor}},
+		},
+		{
+			name:         "unknown key",
+			getErr:       repoerrs.ErrNotFound,
+			unauthorized: true,
+		},
+		{
+			name: "revoked key",
+			stored: func() entity.APIKey {
+				key := active
+				key.RevokedAt = &past
+				return key
+			}(),
+			unauthorized: true,
+		},
+		{
+			name: "expired key",
+			stored: func() entity.APIKey {
+				key := active
+				key.ExpiresAt = &past
+				return key
+			}(),
+			unauthorized: true,
+		},
+		{
+			name:        "repository error",
+			getErr:      errors.New("get error"),
+			expectedErr: errors.New("get error"),
+		},
+		{
+			name:        "touch error",
+			stored:      active,
+			expectTouch: true,
+			touchErr:    errors.New("touch error"),
+			expectLog:   true,
+			expected:    auth.Identity{Subject: "apikey:1", Roles: []auth.Role{auth.RoleReader, auth.RoleEditor}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetAPIKeyByHash(gomock.Any(), gomock.Any()).Return(test.stored, test.getErr)
+			if test.expectTouch {
+				mockRepo.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any(), gomock.Any()).Return(test.touchErr)
+			}
+			if test.expectLog {
+				mockLogger.EXPECT().ErrorfContext(gomock.Any(), gomock.Any(), gomock.Any())
+			}
+
+			identity, err := svc.AuthenticateAPIKey(context.Background(), "fio_secret")
+
+			if test.unauthorized {
+				assert.ErrorIs(t, err, auth.ErrUnauthenticated, "Test case %s failed", test.name)
+				return
+			}
+			assert.Equal(t, test.expectedErr, err, "Test case %s failed", test.name)
+			assert.Equal(t, test.expected, identity, "Test case %s failed", test.name)
+		})
+	}
+}
@@ -4,12 +4,31 @@ package people
 import (
 	"context"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"time"
 )
 
 type repository interface {
-	CreatePerson(ctx context.Context, person entity.Person) error
-	UpdatePersonData(ctx context.Context, personID int, person entity.Person) error
-	DeletePersonData(ctx context.Context, personID int) error
-	GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error)
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error)
+	CreatePeople(ctx context.Context, people []entity.Person) ([]int, error)
+	UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error)
+	RevertPersonData(ctx context.Context, personID int, person entity.Person) (entity.Person, error)
+	PatchPersonData(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error)
+	DeletePersonData(ctx context.Context, personID int, expectedVersion int) (entity.Person, error)
+	GetDeletedPeople(ctx context.Context, page int, limit int) ([]entity.Person, error)
+	RestorePerson(ctx context.Context, personID int) (entity.Person, error)
+	PurgeDeletedPeople(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error)
+	GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error)
+	CountPeople(ctx context.Context, filter entity.PeopleFilter) (int, error)
+	GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error)
+	ExportPeople(ctx context.Context, sortBy, sortOrder string, filter entity.PeopleFilter, fn func(entity.Person) error) error
+	SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error)
+	GetPersonByID(ctx context.Context, personID int) (entity.Person, error)
+	GetPersonForUpdate(ctx context.Context, personID int) (entity.Person, error)
 	CheckPersonExists(ctx context.Context, personID int) (bool, error)
+	GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error)
+	GetPersonRevision(ctx context.Context, personID int, revision int) (entity.PersonRevision, error)
+	AddPersonRevisions(ctx context.Context, revisions []entity.PersonRevision) error
+	AddCreationRevisions(ctx context.Context, personIDs []int) error
 }
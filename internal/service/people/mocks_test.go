@@ -7,6 +7,7 @@ package people
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	entity "github.com/khasmag06/effective-mobile-test/internal/entity"
@@ -35,6 +36,34 @@ func (m *Mockrepository) EXPECT() *MockrepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddCreationRevisions mocks base method.
+func (m *Mockrepository) AddCreationRevisions(ctx context.Context, personIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCreationRevisions", ctx, personIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCreationRevisions indicates an expected call of AddCreationRevisions.
+func (mr *MockrepositoryMockRecorder) AddCreationRevisions(ctx, personIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCreationRevisions", reflect.TypeOf((*Mockrepository)(nil).AddCreationRevisions), ctx, personIDs)
+}
+
+// AddPersonRevisions mocks base method.
+func (m *Mockrepository) AddPersonRevisions(ctx context.Context, revisions []entity.PersonRevision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPersonRevisions", ctx, revisions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPersonRevisions indicates an expected call of AddPersonRevisions.
+func (mr *MockrepositoryMockRecorder) AddPersonRevisions(ctx, revisions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPersonRevisions", reflect.TypeOf((*Mockrepository)(nil).AddPersonRevisions), ctx, revisions)
+}
+
 // CheckPersonExists mocks base method.
 func (m *Mockrepository) CheckPersonExists(ctx context.Context, personID int) (bool, error) {
 	m.ctrl.T.Helper()
@@ -50,12 +79,43 @@ func (mr *MockrepositoryMockRecorder) CheckPersonExists(ctx, personID interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPersonExists", reflect.TypeOf((*Mockrepository)(nil).CheckPersonExists), ctx, personID)
 }
 
+// CountPeople mocks base method.
+func (m *Mockrepository) CountPeople(ctx context.Context, filter entity.PeopleFilter) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPeople", ctx, filter)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPeople indicates an expected call of CountPeople.
+func (mr *MockrepositoryMockRecorder) CountPeople(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPeople", reflect.TypeOf((*Mockrepository)(nil).CountPeople), ctx, filter)
+}
+
+// CreatePeople mocks base method.
+func (m *Mockrepository) CreatePeople(ctx context.Context, people []entity.Person) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePeople", ctx, people)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePeople indicates an expected call of CreatePeople.
+func (mr *MockrepositoryMockRecorder) CreatePeople(ctx, people interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePeople", reflect.TypeOf((*Mockrepository)(nil).CreatePeople), ctx, people)
+}
+
 // CreatePerson mocks base method.
-func (m *Mockrepository) CreatePerson(ctx context.Context, person entity.Person) error {
+func (m *Mockrepository) CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreatePerson", ctx, person)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreatePerson indicates an expected call of CreatePerson.
@@ -65,44 +125,254 @@ func (mr *MockrepositoryMockRecorder) CreatePerson(ctx, person interface{}) *gom
 }
 
 // DeletePersonData mocks base method.
-func (m *Mockrepository) DeletePersonData(ctx context.Context, personID int) error {
+func (m *Mockrepository) DeletePersonData(ctx context.Context, personID, expectedVersion int) (entity.Person, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePersonData", ctx, personID)
+	ret := m.ctrl.Call(m, "DeletePersonData", ctx, personID, expectedVersion)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePersonData indicates an expected call of DeletePersonData.
+func (mr *MockrepositoryMockRecorder) DeletePersonData(ctx, personID, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePersonData", reflect.TypeOf((*Mockrepository)(nil).DeletePersonData), ctx, personID, expectedVersion)
+}
+
+// ExportPeople mocks base method.
+func (m *Mockrepository) ExportPeople(ctx context.Context, sortBy, sortOrder string, filter entity.PeopleFilter, fn func(entity.Person) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportPeople", ctx, sortBy, sortOrder, filter, fn)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeletePersonData indicates an expected call of DeletePersonData.
-func (mr *MockrepositoryMockRecorder) DeletePersonData(ctx, personID interface{}) *gomock.Call {
+// ExportPeople indicates an expected call of ExportPeople.
+func (mr *MockrepositoryMockRecorder) ExportPeople(ctx, sortBy, sortOrder, filter, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePersonData", reflect.TypeOf((*Mockrepository)(nil).DeletePersonData), ctx, personID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportPeople", reflect.TypeOf((*Mockrepository)(nil).ExportPeople), ctx, sortBy, sortOrder, filter, fn)
+}
+
+// GetDeletedPeople mocks base method.
+func (m *Mockrepository) GetDeletedPeople(ctx context.Context, page, limit int) ([]entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedPeople", ctx, page, limit)
+	ret0, _ := ret[0].([]entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedPeople indicates an expected call of GetDeletedPeople.
+func (mr *MockrepositoryMockRecorder) GetDeletedPeople(ctx, page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedPeople", reflect.TypeOf((*Mockrepository)(nil).GetDeletedPeople), ctx, page, limit)
 }
 
 // GetPeople mocks base method.
-func (m *Mockrepository) GetPeople(ctx context.Context, page, limit int, sortBy, sortOrder string) ([]entity.Person, error) {
+func (m *Mockrepository) GetPeople(ctx context.Context, page, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPeople", ctx, page, limit, sortBy, sortOrder)
+	ret := m.ctrl.Call(m, "GetPeople", ctx, page, limit, sortBy, sortOrder, filter)
 	ret0, _ := ret[0].([]entity.Person)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPeople indicates an expected call of GetPeople.
-func (mr *MockrepositoryMockRecorder) GetPeople(ctx, page, limit, sortBy, sortOrder interface{}) *gomock.Call {
+func (mr *MockrepositoryMockRecorder) GetPeople(ctx, page, limit, sortBy, sortOrder, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeople", reflect.TypeOf((*Mockrepository)(nil).GetPeople), ctx, page, limit, sortBy, sortOrder)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeople", reflect.TypeOf((*Mockrepository)(nil).GetPeople), ctx, page, limit, sortBy, sortOrder, filter)
 }
 
-// UpdatePersonData mocks base method.
-func (m *Mockrepository) UpdatePersonData(ctx context.Context, personID int, person entity.Person) error {
+// GetPeopleByCursor mocks base method.
+func (m *Mockrepository) GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPeopleByCursor", ctx, cursor, limit, sortBy, sortOrder, filter)
+	ret0, _ := ret[0].(entity.PeopleCursorPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeopleByCursor indicates an expected call of GetPeopleByCursor.
+func (mr *MockrepositoryMockRecorder) GetPeopleByCursor(ctx, cursor, limit, sortBy, sortOrder, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeopleByCursor", reflect.TypeOf((*Mockrepository)(nil).GetPeopleByCursor), ctx, cursor, limit, sortBy, sortOrder, filter)
+}
+
+// GetPeopleStats mocks base method.
+func (m *Mockrepository) GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdatePersonData", ctx, personID, person)
+	ret := m.ctrl.Call(m, "GetPeopleStats", ctx, query)
+	ret0, _ := ret[0].(entity.PeopleStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPeopleStats indicates an expected call of GetPeopleStats.
+func (mr *MockrepositoryMockRecorder) GetPeopleStats(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPeopleStats", reflect.TypeOf((*Mockrepository)(nil).GetPeopleStats), ctx, query)
+}
+
+// GetPersonByID mocks base method.
+func (m *Mockrepository) GetPersonByID(ctx context.Context, personID int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonByID", ctx, personID)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonByID indicates an expected call of GetPersonByID.
+func (mr *MockrepositoryMockRecorder) GetPersonByID(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonByID", reflect.TypeOf((*Mockrepository)(nil).GetPersonByID), ctx, personID)
+}
+
+// GetPersonForUpdate mocks base method.
+func (m *Mockrepository) GetPersonForUpdate(ctx context.Context, personID int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonForUpdate", ctx, personID)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonForUpdate indicates an expected call of GetPersonForUpdate.
+func (mr *MockrepositoryMockRecorder) GetPersonForUpdate(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonForUpdate", reflect.TypeOf((*Mockrepository)(nil).GetPersonForUpdate), ctx, personID)
+}
+
+// GetPersonHistory mocks base method.
+func (m *Mockrepository) GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonHistory", ctx, personID)
+	ret0, _ := ret[0].([]entity.PersonRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonHistory indicates an expected call of GetPersonHistory.
+func (mr *MockrepositoryMockRecorder) GetPersonHistory(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonHistory", reflect.TypeOf((*Mockrepository)(nil).GetPersonHistory), ctx, personID)
+}
+
+// GetPersonRevision mocks base method.
+func (m *Mockrepository) GetPersonRevision(ctx context.Context, personID, revision int) (entity.PersonRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonRevision", ctx, personID, revision)
+	ret0, _ := ret[0].(entity.PersonRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonRevision indicates an expected call of GetPersonRevision.
+func (mr *MockrepositoryMockRecorder) GetPersonRevision(ctx, personID, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonRevision", reflect.TypeOf((*Mockrepository)(nil).GetPersonRevision), ctx, personID, revision)
+}
+
+// InTransaction mocks base method.
+func (m *Mockrepository) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockrepositoryMockRecorder) InTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*Mockrepository)(nil).InTransaction), ctx, fn)
+}
+
+// PatchPersonData mocks base method.
+func (m *Mockrepository) PatchPersonData(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchPersonData", ctx, personID, patch, expectedVersion)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchPersonData indicates an expected call of PatchPersonData.
+func (mr *MockrepositoryMockRecorder) PatchPersonData(ctx, personID, patch, expectedVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchPersonData", reflect.TypeOf((*Mockrepository)(nil).PatchPersonData), ctx, personID, patch, expectedVersion)
+}
+
+// PurgeDeletedPeople mocks base method.
+func (m *Mockrepository) PurgeDeletedPeople(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedPeople", ctx, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedPeople indicates an expected call of PurgeDeletedPeople.
+func (mr *MockrepositoryMockRecorder) PurgeDeletedPeople(ctx, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedPeople", reflect.TypeOf((*Mockrepository)(nil).PurgeDeletedPeople), ctx, deletedBefore)
+}
+
+// RestorePerson mocks base method.
+func (m *Mockrepository) RestorePerson(ctx context.Context, personID int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePerson", ctx, personID)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestorePerson indicates an expected call of RestorePerson.
+func (mr *MockrepositoryMockRecorder) RestorePerson(ctx, personID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePerson", reflect.TypeOf((*Mockrepository)(nil).RestorePerson), ctx, personID)
+}
+
+// RevertPersonData mocks base method.
+func (m *Mockrepository) RevertPersonData(ctx context.Context, personID int, person entity.Person) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertPersonData", ctx, personID, person)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertPersonData indicates an expected call of RevertPersonData.
+func (mr *MockrepositoryMockRecorder) RevertPersonData(ctx, personID, person interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertPersonData", reflect.TypeOf((*Mockrepository)(nil).RevertPersonData), ctx, personID, person)
+}
+
+// SearchPeople mocks base method.
+func (m *Mockrepository) SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPeople", ctx, query, limit)
+	ret0, _ := ret[0].([]entity.PersonSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPeople indicates an expected call of SearchPeople.
+func (mr *MockrepositoryMockRecorder) SearchPeople(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPeople", reflect.TypeOf((*Mockrepository)(nil).SearchPeople), ctx, query, limit)
+}
+
+// UpdatePersonData mocks base method.
+func (m *Mockrepository) UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePersonData", ctx, personID, person, expectedVersion)
+	ret0, _ := ret[0].(entity.Person)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // UpdatePersonData indicates an expected call of UpdatePersonData.
-func (mr *MockrepositoryMockRecorder) UpdatePersonData(ctx, personID, person interface{}) *gomock.Call {
+func (mr *MockrepositoryMockRecorder) UpdatePersonData(ctx, personID, person, expectedVersion interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePersonData", reflect.TypeOf((*Mockrepository)(nil).UpdatePersonData), ctx, personID, person)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePersonData", reflect.TypeOf((*Mockrepository)(nil).UpdatePersonData), ctx, personID, person, expectedVersion)
 }
@@ -4,50 +4,150 @@ import (
 	"context"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
 	"github.com/khasmag06/effective-mobile-test/internal/repo/people/repoerrs"
+	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"slices"
+	"time"
 )
 
 type service struct {
 	repo repository
+	*validator.CustomValidator
 }
 
 func New(r repository) *service {
 	return &service{
-		repo: r,
+		repo:            r,
+		CustomValidator: validator.NewCustomValidator(),
 	}
 }
 
-func (s *service) CreatePerson(ctx context.Context, person entity.Person) error {
-	return s.repo.CreatePerson(ctx, person)
+// CreatePerson stores the person and returns it with the id and the version assigned by the repository.
+// The creation is recorded in the history in the same transaction.
+func (s *service) CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error) {
+	var created entity.Person
+	err := s.repo.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.repo.CreatePerson(ctx, person); err != nil {
+			return err
+		}
+		return s.repo.AddPersonRevisions(ctx, []entity.PersonRevision{newRevision(ctx, entity.OperationCreate, nil, created)})
+	})
+	if err != nil {
+		return entity.Person{}, err
+	}
+	return created, nil
 }
 
-func (s *service) UpdatePersonData(ctx context.Context, personID int, person entity.Person) error {
-	exists, err := s.repo.CheckPersonExists(ctx, personID)
-	if err != nil {
-		return err
+// CreatePeople validates every person and inserts the valid ones in a single batch, recording their
+// creation in the history in the same transaction. The result reports the outcome of each item by its
+// index in the input.
+func (s *service) CreatePeople(ctx context.Context, people []entity.Person) (entity.BulkCreateResult, error) {
+	result := s.ValidatePeople(people)
+
+	valid := make([]entity.Person, 0, len(people)-result.Failed)
+	for i, itemResult := range result.Results {
+		if itemResult.Status != entity.BulkStatusValid {
+			continue
+		}
+		valid = append(valid, people[i])
+		result.Results[i].Status = entity.BulkStatusCreated
 	}
 
-	if !exists {
-		return repoerrs.ErrNotFound
+	if len(valid) > 0 {
+		err := s.repo.InTransaction(ctx, func(ctx context.Context) error {
+			ids, err := s.repo.CreatePeople(ctx, valid)
+			if err != nil {
+				return err
+			}
+			return s.repo.AddCreationRevisions(ctx, ids)
+		})
+		if err != nil {
+			return entity.BulkCreateResult{}, err
+		}
 	}
+	result.Created = len(valid)
 
-	return s.repo.UpdatePersonData(ctx, personID, person)
+	return result, nil
 }
 
-func (s *service) DeletePersonData(ctx context.Context, personID int) error {
-	exists, err := s.repo.CheckPersonExists(ctx, personID)
-	if err != nil {
-		return err
+// ValidatePeople checks every person without writing anything, the valid items are reported
+// with the valid status.
+func (s *service) ValidatePeople(people []entity.Person) entity.BulkCreateResult {
+	result := entity.BulkCreateResult{Results: make([]entity.BulkItemResult, len(people))}
+	for i, person := range people {
+		result.Results[i] = entity.BulkItemResult{Index: i, Status: entity.BulkStatusValid}
+		if err := s.Validate(person); err != nil {
+			result.Results[i].Status = entity.BulkStatusInvalid
+			result.Results[i].Error = err.Error()
+			result.Failed++
+		}
 	}
+	return result
+}
+
+// UpdatePersonData overwrites the person. A non-zero expectedVersion must match the stored version.
+func (s *service) UpdatePersonData(ctx context.Context, personID int, person entity.Person, expectedVersion int) (entity.Person, error) {
+	return s.changePerson(ctx, personID, entity.OperationUpdate, func(ctx context.Context, _ entity.Person) (entity.Person, error) {
+		return s.repo.UpdatePersonData(ctx, personID, person, expectedVersion)
+	})
+}
+
+// PatchPerson merges the patch into the stored person, validates the result and writes back only
+// the patched columns. The person is read and locked in the transaction of the write, so the merge
+// is based on the stored row rather than on a possibly stale cached copy.
+func (s *service) PatchPerson(ctx context.Context, personID int, patch entity.PersonPatch, expectedVersion int) (entity.Person, error) {
+	return s.changePerson(ctx, personID, entity.OperationUpdate, func(ctx context.Context, person entity.Person) (entity.Person, error) {
+		if person.DeletedAt != nil {
+			return entity.Person{}, repoerrs.ErrNotFound
+		}
+		if expectedVersion != 0 && expectedVersion != person.Version {
+			return entity.Person{}, repoerrs.ErrConflict
+		}
+
+		patched := patch.Apply(person)
+		if err := s.Validate(patched); err != nil {
+			return entity.Person{}, err
+		}
+		if patch.IsEmpty() {
+			return patched, nil
+		}
 
-	if !exists {
-		return repoerrs.ErrNotFound
+		return s.repo.PatchPersonData(ctx, personID, patch, person.Version)
+	})
+}
+
+func (s *service) DeletePersonData(ctx context.Context, personID int, expectedVersion int) error {
+	_, err := s.changePerson(ctx, personID, entity.OperationDelete, func(ctx context.Context, _ entity.Person) (entity.Person, error) {
+		return s.repo.DeletePersonData(ctx, personID, expectedVersion)
+	})
+	return err
+}
+
+// GetDeletedPeople returns a page of people in the trash.
+func (s *service) GetDeletedPeople(ctx context.Context, page int, limit int) ([]entity.Person, error) {
+	people, err := s.repo.GetDeletedPeople(ctx, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	if people == nil {
+		return []entity.Person{}, nil
 	}
+	return people, nil
+}
 
-	return s.repo.DeletePersonData(ctx, personID)
+func (s *service) RestorePerson(ctx context.Context, personID int) (entity.Person, error) {
+	return s.changePerson(ctx, personID, entity.OperationRestore, func(ctx context.Context, _ entity.Person) (entity.Person, error) {
+		return s.repo.RestorePerson(ctx, personID)
+	})
 }
 
-func (s *service) GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string) ([]entity.Person, error) {
-	people, err := s.repo.GetPeople(ctx, page, limit, sortBy, sortOrder)
+// PurgeDeletedPeople permanently removes people that have stayed in the trash longer than the retention period.
+func (s *service) PurgeDeletedPeople(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeletedPeople(ctx, time.Now().Add(-retention))
+}
+
+func (s *service) GetPeople(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) ([]entity.Person, error) {
+	people, err := s.repo.GetPeople(ctx, page, limit, sortBy, sortOrder, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -56,3 +156,150 @@ func (s *service) GetPeople(ctx context.Context, page int, limit int, sortBy, so
 	}
 	return people, nil
 }
+
+func (s *service) GetPeoplePage(ctx context.Context, page int, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeoplePage, error) {
+	if limit > entity.MaxPaginationLimit {
+		limit = entity.MaxPaginationLimit
+	}
+
+	people, err := s.GetPeople(ctx, page, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return entity.PeoplePage{}, err
+	}
+	total, err := s.repo.CountPeople(ctx, filter)
+	if err != nil {
+		return entity.PeoplePage{}, err
+	}
+
+	return entity.PeoplePage{
+		Items:      people,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: (total + limit - 1) / limit,
+	}, nil
+}
+
+func (s *service) GetPeopleByCursor(ctx context.Context, cursor string, limit int, sortBy, sortOrder string, filter entity.PeopleFilter) (entity.PeopleCursorPage, error) {
+	page, err := s.repo.GetPeopleByCursor(ctx, cursor, limit, sortBy, sortOrder, filter)
+	if err != nil {
+		return entity.PeopleCursorPage{}, err
+	}
+	if page.People == nil {
+		page.People = []entity.Person{}
+	}
+	return page, nil
+}
+
+// Defaults of the statistics parameters the caller leaves out.
+const (
+	defaultTopNationalities = 10
+	defaultGrowthInterval   = entity.GrowthMonth
+)
+
+var defaultAgeBuckets = []int{18, 25, 35, 45, 55, 65}
+
+// GetPeopleStats fills in the parameters left out with the defaults, validates them and computes
+// the statistics of the people matching the filter. The age buckets may come in any order.
+func (s *service) GetPeopleStats(ctx context.Context, query entity.PeopleStatsQuery) (entity.PeopleStats, error) {
+	if query.TopNationalities == 0 {
+		query.TopNationalities = defaultTopNationalities
+	}
+	if query.GrowthInterval == "" {
+		query.GrowthInterval = defaultGrowthInterval
+	}
+	if len(query.AgeBuckets) == 0 {
+		query.AgeBuckets = defaultAgeBuckets
+	}
+	query.AgeBuckets = slices.Clone(query.AgeBuckets)
+	slices.Sort(query.AgeBuckets)
+	query.AgeBuckets = slices.Compact(query.AgeBuckets)
+
+	if err := s.Validate(query); err != nil {
+		return entity.PeopleStats{}, err
+	}
+	return s.repo.GetPeopleStats(ctx, query)
+}
+
+func (s *service) ExportPeople(ctx context.Context, sortBy, sortOrder string, filter entity.PeopleFilter, fn func(entity.Person) error) error {
+	return s.repo.ExportPeople(ctx, sortBy, sortOrder, filter, fn)
+}
+
+func (s *service) SearchPeople(ctx context.Context, query string, limit int) ([]entity.PersonSearchResult, error) {
+	results, err := s.repo.SearchPeople(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		return []entity.PersonSearchResult{}, nil
+	}
+	return results, nil
+}
+
+func (s *service) GetPersonByID(ctx context.Context, personID int) (entity.Person, error) {
+	return s.repo.GetPersonByID(ctx, personID)
+}
+
+// GetPersonHistory returns the recorded changes of the person, newest first, each with the list
+// of fields it changed.
+func (s *service) GetPersonHistory(ctx context.Context, personID int) ([]entity.PersonRevision, error) {
+	history, err := s.repo.GetPersonHistory(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		return []entity.PersonRevision{}, nil
+	}
+	for i := range history {
+		history[i].Diff = entity.DiffPeople(history[i].Before, history[i].After)
+	}
+	return history, nil
+}
+
+// RevertPerson overwrites the person with the data of the given revision. The revert itself
+// is recorded as a new revision, so it can be reverted as well.
+func (s *service) RevertPerson(ctx context.Context, personID int, revision int) (entity.Person, error) {
+	target, err := s.repo.GetPersonRevision(ctx, personID, revision)
+	if err != nil {
+		return entity.Person{}, err
+	}
+	return s.changePerson(ctx, personID, entity.OperationRevert, func(ctx context.Context, _ entity.Person) (entity.Person, error) {
+		return s.repo.RevertPersonData(ctx, personID, target.After)
+	})
+}
+
+// changePerson locks the person, applies the change to it and records it in the history, all in one
+// transaction. The lock makes the before snapshot of the revision exactly what the change replaced.
+// A change that leaves the version as it was wrote nothing and gets no revision.
+func (s *service) changePerson(ctx context.Context, personID int, operation string, change func(ctx context.Context, before entity.Person) (entity.Person, error)) (entity.Person, error) {
+	var after entity.Person
+	err := s.repo.InTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetPersonForUpdate(ctx, personID)
+		if err != nil {
+			return err
+		}
+		if after, err = change(ctx, before); err != nil {
+			return err
+		}
+		if after.Version == before.Version {
+			return nil
+		}
+		return s.repo.AddPersonRevisions(ctx, []entity.PersonRevision{newRevision(ctx, operation, &before, after)})
+	})
+	if err != nil {
+		return entity.Person{}, err
+	}
+	return after, nil
+}
+
+// newRevision describes a change of the person made on behalf of the actor in ctx.
+func newRevision(ctx context.Context, operation string, before *entity.Person, after entity.Person) entity.PersonRevision {
+	return entity.PersonRevision{
+		PersonID:  after.ID,
+		Revision:  after.Version,
+		Operation: operation,
+		Actor:     entity.ActorFromContext(ctx),
+		Before:    before,
+		After:     after,
+	}
+}
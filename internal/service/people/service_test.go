@@ -7,6 +7,7 @@ import (
 	"github.com/khasmag06/effective-mobile-test/internal/service/people"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
@@ -18,36 +19,60 @@ func TestService_CreatePerson(t *testing.T) {
 
 	mockRepo := people.NewMockrepository(ctrl)
 	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	inputPerson := entity.Person{
+		Name:        "John",
+		Surname:     "Doe",
+		Patronymic:  "Smith",
+		Age:         30,
+		Gender:      "male",
+		Nationality: "American",
+	}
+	createdPerson := inputPerson
+	createdPerson.ID, createdPerson.Version = 1, 1
+	revision := entity.PersonRevision{
+		PersonID:  1,
+		Revision:  1,
+		Operation: entity.OperationCreate,
+		Actor:     entity.Actor{Name: entity.SourceSystem, Source: entity.SourceSystem},
+		After:     createdPerson,
+	}
 
 	tests := []struct {
-		name        string
-		inputPerson entity.Person
-		mockResult  error
-		expectedErr error
+		name           string
+		createErr      error
+		historyErr     error
+		expectedPerson entity.Person
+		expectedErr    error
 	}{
 		{
-			name: "valid person",
-			inputPerson: entity.Person{
-				ID:          1,
-				Name:        "John",
-				Surname:     "Doe",
-				Patronymic:  "Smith",
-				Age:         30,
-				Gender:      "male",
-				Nationality: "American",
-			},
-			mockResult:  nil,
-			expectedErr: nil,
+			name:           "valid person",
+			expectedPerson: createdPerson,
+		},
+		{
+			name:        "create error",
+			createErr:   errors.New("create error"),
+			expectedErr: errors.New("create error"),
+		},
+		{
+			name:        "history error",
+			historyErr:  errors.New("history error"),
+			expectedErr: errors.New("history error"),
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			mockRepo.EXPECT().CreatePerson(gomock.Any(), test.inputPerson).Return(test.mockResult)
+			mockRepo.EXPECT().CreatePerson(gomock.Any(), inputPerson).Return(createdPerson, test.createErr)
+			if test.createErr == nil {
+				mockRepo.EXPECT().AddPersonRevisions(gomock.Any(), []entity.PersonRevision{revision}).Return(test.historyErr)
+			}
 
-			err := svc.CreatePerson(context.Background(), test.inputPerson)
+			person, err := svc.CreatePerson(context.Background(), inputPerson)
 
 			assert.Equal(t, test.expectedErr, err, "Test case %s failed", test.name)
+			assert.Equal(t, test.expectedPerson, person, "Test case %s failed", test.name)
 		})
 	}
 }
@@ -58,95 +83,78 @@ func TestService_UpdatePersonData(t *testing.T) {
 
 	mockRepo := people.NewMockrepository(ctrl)
 	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	inputPerson := entity.Person{
+		Name:        "John",
+		Surname:     "Doe",
+		Patronymic:  "Smith",
+		Age:         31,
+		Gender:      "male",
+		Nationality: "American",
+	}
+	updatedPerson := inputPerson
+	updatedPerson.ID, updatedPerson.Version = 1, 3
+	updatedPerson.CreatedAt = time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	updatedPerson.UpdatedAt = time.Date(2023, 10, 2, 12, 0, 0, 0, time.UTC)
+	storedPerson := updatedPerson
+	storedPerson.Age, storedPerson.Version = 30, 2
 
 	tests := []struct {
 		name           string
-		personID       int
-		inputPerson    entity.Person
-		existsInRepo   bool
-		checkExistsErr error
+		version        int
 		updateErr      error
+		expectedPerson entity.Person
 		expectedErr    error
 	}{
 		{
-			name:     "valid update",
-			personID: 1,
-			inputPerson: entity.Person{
-				ID:          1,
-				Name:        "John",
-				Surname:     "Doe",
-				Patronymic:  "Smith",
-				Age:         30,
-				Gender:      "male",
-				Nationality: "American",
-			},
-			existsInRepo:   true,
-			checkExistsErr: nil,
-			updateErr:      nil,
+			name:           "valid update",
+			expectedPerson: updatedPerson,
 			expectedErr:    nil,
 		},
 		{
-			name:     "person not found",
-			personID: 2,
-			inputPerson: entity.Person{
-				ID:          2,
-				Name:        "Alice",
-				Surname:     "Smith",
-				Patronymic:  "Johnson",
-				Age:         25,
-				Gender:      "female",
-				Nationality: "Canadian",
-			},
-			existsInRepo:   false,
-			checkExistsErr: nil,
-			updateErr:      nil,
-			expectedErr:    repoerrs.ErrNotFound,
-		},
-		{
-			name:     "check exits person",
-			personID: 3,
-			inputPerson: entity.Person{
-				ID:          3,
-				Name:        "Bob",
-				Surname:     "Brown",
-				Patronymic:  "Williams",
-				Age:         35,
-				Gender:      "male",
-				Nationality: "British",
-			},
-			existsInRepo:   false,
-			checkExistsErr: errors.New("check exists error"),
-			updateErr:      nil,
-			expectedErr:    errors.New("check exists error"),
-		},
-		{
-			name:     "Update Error",
-			personID: 4,
-			inputPerson: entity.Person{
-				ID:          4,
-				Name:        "Eva",
-				Surname:     "Johnson",
-				Patronymic:  "Davis",
-				Age:         28,
-				Gender:      "female",
-				Nationality: "Australian",
-			},
-			existsInRepo:   true,
-			checkExistsErr: nil,
-			updateErr:      errors.New("update error"),
-			expectedErr:    errors.New("update error"),
+			name:           "matching version",
+			version:        2,
+			expectedPerson: updatedPerson,
+			expectedErr:    nil,
+		},
+		{
+			name:        "stale version",
+			version:     1,
+			updateErr:   repoerrs.ErrConflict,
+			expectedErr: repoerrs.ErrConflict,
+		},
+		{
+			name:        "person not found",
+			updateErr:   repoerrs.ErrNotFound,
+			expectedErr: repoerrs.ErrNotFound,
+		},
+		{
+			name:        "Update Error",
+			updateErr:   errors.New("update error"),
+			expectedErr: errors.New("update error"),
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			mockRepo.EXPECT().CheckPersonExists(gomock.Any(), test.personID).Return(test.existsInRepo, test.checkExistsErr)
-			if test.existsInRepo {
-				mockRepo.EXPECT().UpdatePersonData(gomock.Any(), test.personID, test.inputPerson).Return(test.updateErr)
+			mockRepo.EXPECT().GetPersonForUpdate(gomock.Any(), updatedPerson.ID).Return(storedPerson, nil)
+			mockRepo.EXPECT().UpdatePersonData(gomock.Any(), updatedPerson.ID, inputPerson, test.version).Return(test.expectedPerson, test.updateErr)
+			if test.updateErr == nil {
+				mockRepo.EXPECT().AddPersonRevisions(gomock.Any(), []entity.PersonRevision{{
+					PersonID:  1,
+					Revision:  3,
+					Operation: entity.OperationUpdate,
+					Actor:     entity.Actor{Name: entity.SourceSystem, Source: entity.SourceSystem},
+					Before:    &storedPerson,
+					After:     updatedPerson,
+				}}).Return(nil)
 			}
-			err := svc.UpdatePersonData(context.Background(), test.personID, test.inputPerson)
+
+			person, err := svc.UpdatePersonData(context.Background(), updatedPerson.ID, inputPerson, test.version)
 
 			assert.Equal(t, test.expectedErr, err, "Test case %s failed", test.name)
+			assert.Equal(t, test.expectedPerson, person, "Test case %s failed", test.name)
 		})
 	}
 }
@@ -157,57 +165,64 @@ func TestService_DeletePersonData(t *testing.T) {
 
 	mockRepo := people.NewMockrepository(ctrl)
 	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	deletedAt := time.Date(2023, 10, 2, 12, 0, 0, 0, time.UTC)
+	stored := entity.Person{ID: 1, Name: "Ivan", Version: 2}
+	deleted := entity.Person{ID: 1, Name: "Ivan", Version: 3, DeletedAt: &deletedAt}
 
 	tests := []struct {
-		name           string
-		personID       int
-		existsInRepo   bool
-		checkExistsErr error
-		deleteErr      error
-		expectedErr    error
+		name        string
+		version     int
+		lockErr     error
+		deleteErr   error
+		expectedErr error
 	}{
 		{
-			name:           "valid deletion",
-			personID:       1,
-			existsInRepo:   true,
-			checkExistsErr: nil,
-			deleteErr:      nil,
-			expectedErr:    nil,
+			name:        "valid deletion",
+			expectedErr: nil,
 		},
 		{
-			name:           "person not found",
-			personID:       2,
-			existsInRepo:   false,
-			checkExistsErr: nil,
-			deleteErr:      nil,
-			expectedErr:    repoerrs.ErrNotFound,
+			name:        "person not found before the lock",
+			lockErr:     repoerrs.ErrNotFound,
+			expectedErr: repoerrs.ErrNotFound,
+		},
+		{
+			name:        "stale version",
+			version:     1,
+			deleteErr:   repoerrs.ErrConflict,
+			expectedErr: repoerrs.ErrConflict,
 		},
 		{
-			name:           "check exists person",
-			personID:       3,
-			existsInRepo:   false,
-			checkExistsErr: errors.New("check exists error"),
-			deleteErr:      nil,
-			expectedErr:    errors.New("check exists error"),
+			name:        "person not found",
+			deleteErr:   repoerrs.ErrNotFound,
+			expectedErr: repoerrs.ErrNotFound,
 		},
 		{
-			name:           "delete error",
-			personID:       4,
-			existsInRepo:   true,
-			checkExistsErr: nil,
-			deleteErr:      errors.New("delete error"),
-			expectedErr:    errors.New("delete error"),
+			name:        "delete error",
+			deleteErr:   errors.New("delete error"),
+			expectedErr: errors.New("delete error"),
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			mockRepo.EXPECT().CheckPersonExists(gomock.Any(), test.personID).Return(test.existsInRepo, test.checkExistsErr)
-
-			if test.existsInRepo {
-				mockRepo.EXPECT().DeletePersonData(gomock.Any(), test.personID).Return(test.deleteErr)
+			mockRepo.EXPECT().GetPersonForUpdate(gomock.Any(), 1).Return(stored, test.lockErr)
+			if test.lockErr == nil {
+				mockRepo.EXPECT().DeletePersonData(gomock.Any(), 1, test.version).Return(deleted, test.deleteErr)
+			}
+			if test.lockErr == nil && test.deleteErr == nil {
+				mockRepo.EXPECT().AddPersonRevisions(gomock.Any(), []entity.PersonRevision{{
+					PersonID:  1,
+					Revision:  3,
+					Operation: entity.OperationDelete,
+					Actor:     entity.Actor{Name: entity.SourceSystem, Source: entity.SourceSystem},
+					Before:    &stored,
+					After:     deleted,
+				}}).Return(nil)
 			}
-			err := svc.DeletePersonData(context.Background(), test.personID)
+
+			err := svc.DeletePersonData(context.Background(), 1, test.version)
 
 			assert.Equal(t, test.expectedErr, err, "Test case %s failed", test.name)
 		})
@@ -227,6 +242,7 @@ func TestService_GetPeople(t *testing.T) {
 		limit          int
 		sortBy         string
 		sortOrder      string
+		filter         entity.PeopleFilter
 		repoResult     []entity.Person
 		repoError      error
 		expectedPeople []entity.Person
@@ -243,6 +259,18 @@ func TestService_GetPeople(t *testing.T) {
 			expectedPeople: []entity.Person{{ID: 1, Name: "John"}, {ID: 2, Name: "Alice"}},
 			expectedError:  nil,
 		},
+		{
+			name:           "filtered result",
+			page:           1,
+			limit:          10,
+			sortBy:         "age",
+			sortOrder:      "desc",
+			filter:         entity.PeopleFilter{Gender: "female", Nationalities: []string{"RU", "KZ"}},
+			repoResult:     []entity.Person{{ID: 2, Name: "Alice", Gender: "female"}},
+			repoError:      nil,
+			expectedPeople: []entity.Person{{ID: 2, Name: "Alice", Gender: "female"}},
+			expectedError:  nil,
+		},
 		{
 			name:           "empty result",
 			page:           1,
@@ -269,12 +297,783 @@ func TestService_GetPeople(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			mockRepo.EXPECT().GetPeople(gomock.Any(), test.page, test.limit, test.sortBy, test.sortOrder).Return(test.repoResult, test.repoError)
+			mockRepo.EXPECT().GetPeople(gomock.Any(), test.page, test.limit, test.sortBy, test.sortOrder, test.filter).Return(test.repoResult, test.repoError)
 
-			people, err := svc.GetPeople(context.Background(), test.page, test.limit, test.sortBy, test.sortOrder)
+			people, err := svc.GetPeople(context.Background(), test.page, test.limit, test.sortBy, test.sortOrder, test.filter)
 
 			assert.Equal(t, test.expectedPeople, people, "Test case %s failed: People not as expected", test.name)
 			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
 		})
 	}
 }
+
+func TestService_GetPersonByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	tests := []struct {
+		name           string
+		personID       int
+		repoResult     entity.Person
+		repoError      error
+		expectedPerson entity.Person
+		expectedError  error
+	}{
+		{
+			name:           "valid result",
+			personID:       1,
+			repoResult:     entity.Person{ID: 1, Name: "John"},
+			repoError:      nil,
+			expectedPerson: entity.Person{ID: 1, Name: "John"},
+			expectedError:  nil,
+		},
+		{
+			name:           "person not found",
+			personID:       2,
+			repoResult:     entity.Person{},
+			repoError:      repoerrs.ErrNotFound,
+			expectedPerson: entity.Person{},
+			expectedError:  repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetPersonByID(gomock.Any(), test.personID).Return(test.repoResult, test.repoError)
+
+			person, err := svc.GetPersonByID(context.Background(), test.personID)
+
+			assert.Equal(t, test.expectedPerson, person, "Test case %s failed: Person not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_GetPeopleByCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	tests := []struct {
+		name          string
+		cursor        string
+		limit         int
+		repoResult    entity.PeopleCursorPage
+		repoError     error
+		expectedPage  entity.PeopleCursorPage
+		expectedError error
+	}{
+		{
+			name:          "first page",
+			cursor:        "",
+			limit:         2,
+			repoResult:    entity.PeopleCursorPage{People: []entity.Person{{ID: 1}, {ID: 2}}, NextCursor: "next"},
+			repoError:     nil,
+			expectedPage:  entity.PeopleCursorPage{People: []entity.Person{{ID: 1}, {ID: 2}}, NextCursor: "next"},
+			expectedError: nil,
+		},
+		{
+			name:          "empty page",
+			cursor:        "next",
+			limit:         2,
+			repoResult:    entity.PeopleCursorPage{},
+			repoError:     nil,
+			expectedPage:  entity.PeopleCursorPage{People: []entity.Person{}},
+			expectedError: nil,
+		},
+		{
+			name:          "invalid cursor",
+			cursor:        "broken",
+			limit:         2,
+			repoResult:    entity.PeopleCursorPage{},
+			repoError:     repoerrs.ErrInvalidCursor,
+			expectedPage:  entity.PeopleCursorPage{},
+			expectedError: repoerrs.ErrInvalidCursor,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetPeopleByCursor(gomock.Any(), test.cursor, test.limit, "date", "asc", entity.PeopleFilter{}).Return(test.repoResult, test.repoError)
+
+			page, err := svc.GetPeopleByCursor(context.Background(), test.cursor, test.limit, "date", "asc", entity.PeopleFilter{})
+
+			assert.Equal(t, test.expectedPage, page, "Test case %s failed: Page not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_GetPeopleStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	filter := entity.PeopleFilter{Gender: "female"}
+	stats := entity.PeopleStats{Total: 3, Genders: []entity.GenderCount{{Gender: "female", Count: 3}}}
+
+	tests := []struct {
+		name          string
+		query         entity.PeopleStatsQuery
+		expectRepo    bool
+		repoQuery     entity.PeopleStatsQuery
+		repoError     error
+		expectedStats entity.PeopleStats
+		expectedError bool
+	}{
+		{
+			name:          "defaults",
+			query:         entity.PeopleStatsQuery{Filter: filter},
+			expectRepo:    true,
+			repoQuery:     entity.PeopleStatsQuery{Filter: filter, TopNationalities: 10, AgeBuckets: []int{18, 25, 35, 45, 55, 65}, GrowthInterval: entity.GrowthMonth},
+			expectedStats: stats,
+		},
+		{
+			name:          "unordered buckets",
+			query:         entity.PeopleStatsQuery{TopNationalities: 3, AgeBuckets: []int{60, 18, 30, 18}, GrowthInterval: entity.GrowthYear},
+			expectRepo:    true,
+			repoQuery:     entity.PeopleStatsQuery{TopNationalities: 3, AgeBuckets: []int{18, 30, 60}, GrowthInterval: entity.GrowthYear},
+			expectedStats: stats,
+		},
+		{
+			name:          "too many nationalities",
+			query:         entity.PeopleStatsQuery{TopNationalities: 500},
+			expectedError: true,
+		},
+		{
+			name:          "bucket out of range",
+			query:         entity.PeopleStatsQuery{AgeBuckets: []int{18, 200}},
+			expectedError: true,
+		},
+		{
+			name:          "unknown interval",
+			query:         entity.PeopleStatsQuery{GrowthInterval: "decade"},
+			expectedError: true,
+		},
+		{
+			name:          "repository error",
+			query:         entity.PeopleStatsQuery{Filter: filter},
+			expectRepo:    true,
+			repoQuery:     entity.PeopleStatsQuery{Filter: filter, TopNationalities: 10, AgeBuckets: []int{18, 25, 35, 45, 55, 65}, GrowthInterval: entity.GrowthMonth},
+			repoError:     errors.New("stats error"),
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expectRepo {
+				mockRepo.EXPECT().GetPeopleStats(gomock.Any(), test.repoQuery).Return(test.expectedStats, test.repoError)
+			}
+
+			result, err := svc.GetPeopleStats(context.Background(), test.query)
+
+			assert.Equal(t, test.expectedError, err != nil, "Test case %s failed: Error not as expected", test.name)
+			if !test.expectedError {
+				assert.Equal(t, test.expectedStats, result, "Test case %s failed: Stats not as expected", test.name)
+			}
+		})
+	}
+}
+
+func TestService_GetPeoplePage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	filter := entity.PeopleFilter{Gender: "male"}
+
+	tests := []struct {
+		name          string
+		page          int
+		limit         int
+		repoLimit     int
+		repoResult    []entity.Person
+		repoError     error
+		countResult   int
+		countError    error
+		expectedPage  entity.PeoplePage
+		expectedError error
+	}{
+		{
+			name:         "valid page",
+			page:         2,
+			limit:        2,
+			repoLimit:    2,
+			repoResult:   []entity.Person{{ID: 3}, {ID: 4}},
+			countResult:  5,
+			expectedPage: entity.PeoplePage{Items: []entity.Person{{ID: 3}, {ID: 4}}, Total: 5, Page: 2, Limit: 2, TotalPages: 3},
+		},
+		{
+			name:         "limit above maximum",
+			page:         1,
+			limit:        100,
+			repoLimit:    entity.MaxPaginationLimit,
+			repoResult:   nil,
+			countResult:  0,
+			expectedPage: entity.PeoplePage{Items: []entity.Person{}, Total: 0, Page: 1, Limit: entity.MaxPaginationLimit, TotalPages: 0},
+		},
+		{
+			name:          "count error",
+			page:          1,
+			limit:         10,
+			repoLimit:     10,
+			repoResult:    []entity.Person{{ID: 1}},
+			countError:    errors.New("count error"),
+			expectedPage:  entity.PeoplePage{},
+			expectedError: errors.New("count error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetPeople(gomock.Any(), test.page, test.repoLimit, "date", "asc", filter).Return(test.repoResult, test.repoError)
+			mockRepo.EXPECT().CountPeople(gomock.Any(), filter).Return(test.countResult, test.countError)
+
+			page, err := svc.GetPeoplePage(context.Background(), test.page, test.limit, "date", "asc", filter)
+
+			assert.Equal(t, test.expectedPage, page, "Test case %s failed: Page not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_PatchPerson(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	stored := entity.Person{
+		ID:          1,
+		Name:        "John",
+		Surname:     "Doe",
+		Patronymic:  "Smith",
+		Age:         30,
+		Gender:      "male",
+		Nationality: "US",
+		Version:     3,
+	}
+	deletedAt := time.Date(2023, 10, 2, 12, 0, 0, 0, time.UTC)
+	trashed := stored
+	trashed.DeletedAt = &deletedAt
+	patronymic, lowerName := "Jameson", "john"
+
+	tests := []struct {
+		name           string
+		patch          entity.PersonPatch
+		version        int
+		lockResult     *entity.Person
+		lockErr        error
+		expectPatch    bool
+		patchErr       error
+		expectedPerson entity.Person
+		expectedErr    bool
+	}{
+		{
+			name:        "valid patch",
+			patch:       entity.PersonPatch{Patronymic: &patronymic},
+			expectPatch: true,
+			expectedPerson: entity.Person{
+				ID:          1,
+				Name:        "John",
+				Surname:     "Doe",
+				Patronymic:  "Jameson",
+				Age:         30,
+				Gender:      "male",
+				Nationality: "US",
+				Version:     4,
+			},
+		},
+		{
+			name:        "matching version",
+			patch:       entity.PersonPatch{Patronymic: &patronymic},
+			version:     3,
+			expectPatch: true,
+			expectedPerson: entity.Person{
+				ID:          1,
+				Name:        "John",
+				Surname:     "Doe",
+				Patronymic:  "Jameson",
+				Age:         30,
+				Gender:      "male",
+				Nationality: "US",
+				Version:     4,
+			},
+		},
+		{
+			name:        "stale version",
+			patch:       entity.PersonPatch{Patronymic: &patronymic},
+			version:     2,
+			expectedErr: true,
+		},
+		{
+			name:           "empty patch",
+			patch:          entity.PersonPatch{},
+			expectedPerson: stored,
+		},
+		{
+			name:        "invalid merged person",
+			patch:       entity.PersonPatch{Name: &lowerName},
+			expectedErr: true,
+		},
+		{
+			name:        "person not found",
+			patch:       entity.PersonPatch{Patronymic: &patronymic},
+			lockErr:     repoerrs.ErrNotFound,
+			expectedErr: true,
+		},
+		{
+			name:        "person in the trash",
+			patch:       entity.PersonPatch{Patronymic: &patronymic},
+			lockResult:  &trashed,
+			expectedErr: true,
+		},
+		{
+			name:        "patch error",
+			patch:       entity.PersonPatch{Patronymic: &patronymic},
+			expectPatch: true,
+			patchErr:    errors.New("patch error"),
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			locked := stored
+			if test.lockResult != nil {
+				locked = *test.lockResult
+			}
+			mockRepo.EXPECT().GetPersonForUpdate(gomock.Any(), stored.ID).Return(locked, test.lockErr)
+			if test.expectPatch {
+				mockRepo.EXPECT().PatchPersonData(gomock.Any(), stored.ID, test.patch, stored.Version).Return(test.expectedPerson, test.patchErr)
+			}
+			if test.expectPatch && test.patchErr == nil {
+				mockRepo.EXPECT().AddPersonRevisions(gomock.Any(), []entity.PersonRevision{{
+					PersonID:  1,
+					Revision:  4,
+					Operation: entity.OperationUpdate,
+					Actor:     entity.Actor{Name: entity.SourceSystem, Source: entity.SourceSystem},
+					Before:    &stored,
+					After:     test.expectedPerson,
+				}}).Return(nil)
+			}
+
+			person, err := svc.PatchPerson(context.Background(), stored.ID, test.patch, test.version)
+
+			assert.Equal(t, test.expectedErr, err != nil, "Test case %s failed: Error not as expected", test.name)
+			assert.Equal(t, test.expectedPerson, person, "Test case %s failed: Person not as expected", test.name)
+		})
+	}
+}
+
+func TestService_CreatePeople(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	valid := entity.Person{Name: "John", Surname: "Doe", Age: 30, Gender: "male", Nationality: "US"}
+	invalid := entity.Person{Name: "john", Surname: "Doe", Age: 30, Gender: "male", Nationality: "US"}
+
+	tests := []struct {
+		name           string
+		inputPeople    []entity.Person
+		expectedInsert []entity.Person
+		insertedIDs    []int
+		insertErr      error
+		expectedResult entity.BulkCreateResult
+		expectedErr    error
+	}{
+		{
+			name:           "valid and invalid items",
+			inputPeople:    []entity.Person{valid, invalid, valid},
+			expectedInsert: []entity.Person{valid, valid},
+			insertedIDs:    []int{1, 2},
+			expectedResult: entity.BulkCreateResult{
+				Created: 2,
+				Failed:  1,
+				Results: []entity.BulkItemResult{
+					{Index: 0, Status: entity.BulkStatusCreated},
+					{Index: 1, Status: entity.BulkStatusInvalid, Error: "field name must start with an upper case letter"},
+					{Index: 2, Status: entity.BulkStatusCreated},
+				},
+			},
+		},
+		{
+			name:        "only invalid items",
+			inputPeople: []entity.Person{invalid},
+			expectedResult: entity.BulkCreateResult{
+				Created: 0,
+				Failed:  1,
+				Results: []entity.BulkItemResult{
+					{Index: 0, Status: entity.BulkStatusInvalid, Error: "field name must start with an upper case letter"},
+				},
+			},
+		},
+		{
+			name:           "insert error",
+			inputPeople:    []entity.Person{valid},
+			expectedInsert: []entity.Person{valid},
+			insertErr:      errors.New("insert error"),
+			expectedResult: entity.BulkCreateResult{},
+			expectedErr:    errors.New("insert error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expectedInsert != nil {
+				mockRepo.EXPECT().CreatePeople(gomock.Any(), test.expectedInsert).Return(test.insertedIDs, test.insertErr)
+			}
+			if test.insertedIDs != nil {
+				mockRepo.EXPECT().AddCreationRevisions(gomock.Any(), test.insertedIDs).Return(nil)
+			}
+
+			result, err := svc.CreatePeople(context.Background(), test.inputPeople)
+
+			assert.Equal(t, test.expectedResult, result, "Test case %s failed: Result not as expected", test.name)
+			assert.Equal(t, test.expectedErr, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_ValidatePeople(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	inputPeople := []entity.Person{
+		{Name: "John", Surname: "Doe", Age: 30, Gender: "male", Nationality: "US"},
+		{Name: "John", Surname: "Doe", Age: 130, Gender: "male", Nationality: "US"},
+	}
+	expectedResult := entity.BulkCreateResult{
+		Created: 0,
+		Failed:  1,
+		Results: []entity.BulkItemResult{
+			{Index: 0, Status: entity.BulkStatusValid},
+			{Index: 1, Status: entity.BulkStatusInvalid, Error: "field age must be less than or equal to 120"},
+		},
+	}
+
+	result := svc.ValidatePeople(inputPeople)
+
+	assert.Equal(t, expectedResult, result)
+}
+
+func TestService_SearchPeople(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	tests := []struct {
+		name            string
+		query           string
+		repoResult      []entity.PersonSearchResult
+		repoError       error
+		expectedResults []entity.PersonSearchResult
+		expectedError   error
+	}{
+		{
+			name:            "ranked results",
+			query:           "ivanvo",
+			repoResult:      []entity.PersonSearchResult{{Person: entity.Person{ID: 1, Surname: "Ivanov"}, Score: 0.8}},
+			expectedResults: []entity.PersonSearchResult{{Person: entity.Person{ID: 1, Surname: "Ivanov"}, Score: 0.8}},
+		},
+		{
+			name:            "no results",
+			query:           "zzz",
+			repoResult:      nil,
+			expectedResults: []entity.PersonSearchResult{},
+		},
+		{
+			name:          "repo error",
+			query:         "ivan",
+			repoError:     errors.New("repository error"),
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().SearchPeople(gomock.Any(), test.query, 10).Return(test.repoResult, test.repoError)
+
+			results, err := svc.SearchPeople(context.Background(), test.query, 10)
+
+			assert.Equal(t, test.expectedResults, results, "Test case %s failed: Results not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_GetDeletedPeople(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	deletedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		repoResult     []entity.Person
+		repoError      error
+		expectedPeople []entity.Person
+		expectedError  error
+	}{
+		{
+			name:           "deleted people",
+			repoResult:     []entity.Person{{ID: 1, Name: "Ivan", DeletedAt: &deletedAt}},
+			expectedPeople: []entity.Person{{ID: 1, Name: "Ivan", DeletedAt: &deletedAt}},
+		},
+		{
+			name:           "empty trash",
+			repoResult:     nil,
+			expectedPeople: []entity.Person{},
+		},
+		{
+			name:          "repo error",
+			repoError:     errors.New("repository error"),
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetDeletedPeople(gomock.Any(), 1, 10).Return(test.repoResult, test.repoError)
+
+			result, err := svc.GetDeletedPeople(context.Background(), 1, 10)
+
+			assert.Equal(t, test.expectedPeople, result, "Test case %s failed: People not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_RestorePerson(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	deletedAt := time.Date(2023, 10, 2, 12, 0, 0, 0, time.UTC)
+	trashed := entity.Person{ID: 1, Name: "Ivan", Version: 2, DeletedAt: &deletedAt}
+	restored := entity.Person{ID: 1, Name: "Ivan", Version: 3}
+
+	tests := []struct {
+		name           string
+		personID       int
+		repoResult     entity.Person
+		repoError      error
+		expectedPerson entity.Person
+		expectedError  error
+	}{
+		{
+			name:           "restored",
+			personID:       1,
+			repoResult:     restored,
+			expectedPerson: restored,
+		},
+		{
+			name:          "not in trash",
+			personID:      2,
+			repoError:     repoerrs.ErrNotFound,
+			expectedError: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetPersonForUpdate(gomock.Any(), test.personID).Return(trashed, nil)
+			mockRepo.EXPECT().RestorePerson(gomock.Any(), test.personID).Return(test.repoResult, test.repoError)
+			if test.repoError == nil {
+				mockRepo.EXPECT().AddPersonRevisions(gomock.Any(), []entity.PersonRevision{{
+					PersonID:  1,
+					Revision:  3,
+					Operation: entity.OperationRestore,
+					Actor:     entity.Actor{Name: entity.SourceSystem, Source: entity.SourceSystem},
+					Before:    &trashed,
+					After:     restored,
+				}}).Return(nil)
+			}
+
+			person, err := svc.RestorePerson(context.Background(), test.personID)
+
+			assert.Equal(t, test.expectedPerson, person, "Test case %s failed: Person not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_PurgeDeletedPeople(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	retention := 30 * 24 * time.Hour
+	var deletedBefore time.Time
+	mockRepo.EXPECT().PurgeDeletedPeople(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+			deletedBefore = before
+			return 5, nil
+		})
+
+	purged, err := svc.PurgeDeletedPeople(context.Background(), retention)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), purged)
+	assert.WithinDuration(t, time.Now().Add(-retention), deletedBefore, time.Minute)
+}
+
+func TestService_GetPersonHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+
+	before := entity.Person{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU", Version: 1}
+	after := entity.Person{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: 31, Gender: "male", Nationality: "RU", Version: 2}
+
+	tests := []struct {
+		name            string
+		repoResult      []entity.PersonRevision
+		repoError       error
+		expectedHistory []entity.PersonRevision
+		expectedError   error
+	}{
+		{
+			name: "history with diff",
+			repoResult: []entity.PersonRevision{
+				{PersonID: 1, Revision: 2, Operation: entity.OperationUpdate, Before: &before, After: after},
+			},
+			expectedHistory: []entity.PersonRevision{
+				{
+					PersonID:  1,
+					Revision:  2,
+					Operation: entity.OperationUpdate,
+					Before:    &before,
+					After:     after,
+					Diff:      []entity.FieldChange{{Field: "age", From: "30", To: "31"}},
+				},
+			},
+		},
+		{
+			name:            "no history",
+			repoResult:      nil,
+			expectedHistory: []entity.PersonRevision{},
+		},
+		{
+			name:          "repo error",
+			repoError:     errors.New("repository error"),
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetPersonHistory(gomock.Any(), 1).Return(test.repoResult, test.repoError)
+
+			history, err := svc.GetPersonHistory(context.Background(), 1)
+
+			assert.Equal(t, test.expectedHistory, history, "Test case %s failed: History not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+func TestService_RevertPerson(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := people.NewMockrepository(ctrl)
+	svc := people.New(mockRepo)
+	expectTransaction(mockRepo)
+
+	revisionData := entity.Person{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU", Version: 1}
+	reverted := entity.Person{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: 30, Gender: "male", Nationality: "RU", Version: 5}
+	current := entity.Person{ID: 1, Name: "Ivan", Surname: "Ivanov", Age: 31, Gender: "male", Nationality: "RU", Version: 4}
+
+	tests := []struct {
+		name           string
+		revisionErr    error
+		expectRevert   bool
+		revertErr      error
+		expectedPerson entity.Person
+		expectedError  error
+	}{
+		{
+			name:           "reverted",
+			expectRevert:   true,
+			expectedPerson: reverted,
+		},
+		{
+			name:          "revision not found",
+			revisionErr:   repoerrs.ErrRevisionNotFound,
+			expectedError: repoerrs.ErrRevisionNotFound,
+		},
+		{
+			name:          "person deleted",
+			expectRevert:  true,
+			revertErr:     repoerrs.ErrNotFound,
+			expectedError: repoerrs.ErrNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo.EXPECT().GetPersonRevision(gomock.Any(), 1, 1).
+				Return(entity.PersonRevision{PersonID: 1, Revision: 1, After: revisionData}, test.revisionErr)
+			if test.expectRevert {
+				mockRepo.EXPECT().GetPersonForUpdate(gomock.Any(), 1).Return(current, nil)
+				mockRepo.EXPECT().RevertPersonData(gomock.Any(), 1, revisionData).Return(test.expectedPerson, test.revertErr)
+			}
+			if test.expectRevert && test.revertErr == nil {
+				mockRepo.EXPECT().AddPersonRevisions(gomock.Any(), []entity.PersonRevision{{
+					PersonID:  1,
+					Revision:  5,
+					Operation: entity.OperationRevert,
+					Actor:     entity.Actor{Name: entity.SourceSystem, Source: entity.SourceSystem},
+					Before:    &current,
+					After:     reverted,
+				}}).Return(nil)
+			}
+
+			person, err := svc.RevertPerson(context.Background(), 1, 1)
+
+			assert.Equal(t, test.expectedPerson, person, "Test case %s failed: Person not as expected", test.name)
+			assert.Equal(t, test.expectedError, err, "Test case %s failed: Error not as expected", test.name)
+		})
+	}
+}
+
+// expectTransaction makes the mock run the functions passed to InTransaction, like the repository does.
+func expectTransaction(repo *people.Mockrepository) {
+	repo.EXPECT().InTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+			return fn(ctx)
+		}).
+		AnyTimes()
+}
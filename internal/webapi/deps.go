@@ -3,13 +3,18 @@ package webapi
 import (
 	"context"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"time"
 )
 
 type peopleService interface {
-	CreatePerson(ctx context.Context, person entity.Person) error
+	CreatePerson(ctx context.Context, person entity.Person) (entity.Person, error)
+}
+
+type metrics interface {
+	ObserveEnrichment(api string, failed bool, duration time.Duration)
 }
 
 type logger interface {
-	Error(text ...any)
-	Errorf(format string, args ...any)
+	ErrorContext(ctx context.Context, text ...any)
+	ErrorfContext(ctx context.Context, format string, args ...any)
 }
@@ -6,115 +6,166 @@ import (
 	"fmt"
 	"github.com/khasmag06/effective-mobile-test/config"
 	"github.com/khasmag06/effective-mobile-test/internal/entity"
+	"github.com/khasmag06/effective-mobile-test/pkg/tracing"
 	"github.com/khasmag06/effective-mobile-test/pkg/validator"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/errgroup"
 	"net/http"
+	"net/url"
+	"time"
 )
 
+// kafkaActor is recorded in the person history as the author of people created from the FIO queue.
+var kafkaActor = entity.Actor{Name: "fio-consumer", Source: entity.SourceKafka}
+
+var tracer = otel.Tracer("github.com/khasmag06/effective-mobile-test/internal/webapi")
+
 type PersonInfoApi struct {
-	client *http.Client
-	apiCfg config.PersonApiConfig
-	ps     peopleService
-	logger logger
+	client  *http.Client
+	apiCfg  config.PersonApiConfig
+	ps      peopleService
+	metrics metrics
+	logger  logger
 	*validator.CustomValidator
 }
 
-func New(cfg config.PersonApiConfig, ps peopleService, l logger) *PersonInfoApi {
+func New(cfg config.PersonApiConfig, ps peopleService, m metrics, l logger) *PersonInfoApi {
 	return &PersonInfoApi{
-		client:          &http.Client{},
+		client:          &http.Client{Timeout: cfg.Timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
 		apiCfg:          cfg,
 		ps:              ps,
+		metrics:         m,
 		logger:          l,
 		CustomValidator: validator.NewCustomValidator(),
 	}
 }
 
-func (p *PersonInfoApi) AddFioData(msg []byte) error {
+func (p *PersonInfoApi) AddFioData(ctx context.Context, msg []byte) (err error) {
+	ctx, span := tracer.Start(ctx, "PersonInfoApi.AddFioData")
+	defer func() { tracing.End(span, err) }()
+
 	var person *entity.Person
 	if err := json.Unmarshal(msg, &person); err != nil {
 		return fmt.Errorf("error decoding age response: %w", err)
 	}
 
-	g := new(errgroup.Group)
-
-	g.Go(func() error {
-		if err := p.getAge(person); err != nil {
-			p.logger.Error(err)
-			return err
-		}
-		return nil
-	})
-	g.Go(func() error {
-		if err := p.getGender(person); err != nil {
-			p.logger.Error(err)
-			return err
-		}
-		return nil
-	})
-	g.Go(func() error {
-		if err := p.getNationality(person); err != nil {
-			p.logger.Error(err)
-			return err
-		}
-		return nil
-	})
-
-	if err := g.Wait(); err != nil {
+	if err := p.enrich(ctx, person, true, true, true); err != nil {
 		return err
 	}
 
 	if err := p.Validate(person); err != nil {
-		p.logger.Errorf("validation error: %v", err)
+		p.logger.ErrorfContext(ctx, "validation error: %v", err)
 		return err
 	}
-	if err := p.ps.CreatePerson(context.Background(), *person); err != nil {
-		p.logger.Errorf("error adding person to database: %v", err)
+	if _, err := p.ps.CreatePerson(entity.WithActor(ctx, kafkaActor), *person); err != nil {
+		p.logger.ErrorfContext(ctx, "error adding person to database: %v", err)
 		return err
 	}
 	return nil
 }
 
-func (p *PersonInfoApi) getAge(person *entity.Person) error {
-	reqURL := fmt.Sprintf("%s?name=%s", p.apiCfg.AgeURL, person.Name)
-	resp, err := p.client.Get(reqURL)
+// EnrichPerson fills in the age, gender and nationality of the person that are not set yet.
+func (p *PersonInfoApi) EnrichPerson(ctx context.Context, person *entity.Person) error {
+	return p.enrich(ctx, person, person.Age == 0, person.Gender == "", person.Nationality == "")
+}
+
+// enrich requests the chosen fields from the enrichment APIs in parallel. The first failure
+// cancels the calls that are still running.
+func (p *PersonInfoApi) enrich(ctx context.Context, person *entity.Person, age, gender, nationality bool) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	if age {
+		g.Go(func() error { return p.call(ctx, "age", p.getAge, person) })
+	}
+	if gender {
+		g.Go(func() error { return p.call(ctx, "gender", p.getGender, person) })
+	}
+	if nationality {
+		g.Go(func() error { return p.call(ctx, "nationality", p.getNationality, person) })
+	}
+
+	return g.Wait()
+}
+
+// call requests one of the enrichment APIs and records its latency and outcome.
+func (p *PersonInfoApi) call(ctx context.Context, api string, get func(context.Context, *entity.Person) error, person *entity.Person) error {
+	ctx, span := tracer.Start(ctx, "PersonInfoApi.enrich "+api)
+	start := time.Now()
+	err := get(ctx, person)
+	p.metrics.ObserveEnrichment(api, err != nil, time.Since(start))
+	tracing.End(span, err)
+	if err != nil {
+		p.logger.ErrorContext(ctx, err)
+		return err
+	}
+	return nil
+}
+
+// AgeResponse is the agify answer, the age is null for the names it knows nothing about.
+type AgeResponse struct {
+	Age *int `json:"age"`
+}
+
+func (p *PersonInfoApi) getAge(ctx context.Context, person *entity.Person) error {
+	reqURL := fmt.Sprintf("%s?name=%s", p.apiCfg.AgeURL, url.QueryEscape(person.Name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("error building age request: %w", err)
+	}
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error getting age response: %w", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("failed age response: status not ok")
 	}
 
-	defer resp.Body.Close()
-
-	var data map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&data)
+	var response AgeResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
 		return fmt.Errorf("error decoding age response: %w", err)
 	}
-	age := int(data["age"].(float64))
-	person.Age = age
+
+	if response.Age == nil {
+		return fmt.Errorf("no age data available")
+	}
+	person.Age = *response.Age
 
 	return nil
 }
 
-func (p *PersonInfoApi) getGender(person *entity.Person) error {
-	reqURL := fmt.Sprintf("%s?name=%s", p.apiCfg.GenderURL, person.Name)
-	resp, err := p.client.Get(reqURL)
+// GenderResponse is the genderize answer, the gender is null for the names it knows nothing about.
+type GenderResponse struct {
+	Gender *string `json:"gender"`
+}
+
+func (p *PersonInfoApi) getGender(ctx context.Context, person *entity.Person) error {
+	reqURL := fmt.Sprintf("%s?name=%s", p.apiCfg.GenderURL, url.QueryEscape(person.Name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("error building gender request: %w", err)
+	}
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error getting gender response: %w", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("failed gender response: status not ok")
 	}
-	defer resp.Body.Close()
 
-	var data map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&data)
+	var response GenderResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
 		return fmt.Errorf("error decoding gender response: %w", err)
 	}
-	gender := data["gender"].(string)
-	person.Gender = gender
+
+	if response.Gender == nil {
+		return fmt.Errorf("no gender data available")
+	}
+	person.Gender = *response.Gender
 
 	return nil
 }
@@ -128,18 +179,21 @@ type NationalityInfo struct {
 	Probability float64 `json:"probability"`
 }
 
-func (p *PersonInfoApi) getNationality(person *entity.Person) error {
-	reqURL := fmt.Sprintf("%s?name=%s", p.apiCfg.NationalityURL, person.Name)
-	resp, err := p.client.Get(reqURL)
+func (p *PersonInfoApi) getNationality(ctx context.Context, person *entity.Person) error {
+	reqURL := fmt.Sprintf("%s?name=%s", p.apiCfg.NationalityURL, url.QueryEscape(person.Name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("error building nationality request: %w", err)
+	}
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error getting nationality response: %w", err)
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("failed nationality response: status not ok")
 	}
 
-	defer resp.Body.Close()
-
 	var response NationalityResponse
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS people_fio_tsv_idx;
+DROP INDEX IF EXISTS people_fio_trgm_idx;
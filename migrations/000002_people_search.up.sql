@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS people_fio_trgm_idx ON people
+    USING GIN ((lower(name || ' ' || surname || ' ' || coalesce(patronymic, ''))) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS people_fio_tsv_idx ON people
+    USING GIN (to_tsvector('simple', name || ' ' || surname || ' ' || coalesce(patronymic, '')));
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS people_history (
+           id bigserial PRIMARY KEY,
+           person_id INT NOT NULL,
+           revision INT NOT NULL,
+           operation VARCHAR(16) NOT NULL,
+           actor VARCHAR(255) NOT NULL,
+           source VARCHAR(16) NOT NULL,
+           before JSONB,
+           after JSONB NOT NULL,
+           changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+           UNIQUE (person_id, revision)
+);
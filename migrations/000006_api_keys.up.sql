@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+           id bigserial PRIMARY KEY,
+           name VARCHAR(255) NOT NULL,
+           prefix VARCHAR(16) NOT NULL,
+           key_hash CHAR(64) NOT NULL UNIQUE,
+           scopes TEXT[] NOT NULL,
+           created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+           expires_at TIMESTAMP WITH TIME ZONE,
+           last_used_at TIMESTAMP WITH TIME ZONE,
+           revoked_at TIMESTAMP WITH TIME ZONE
+);
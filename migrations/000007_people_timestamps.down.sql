@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS people_updated_at ON people;
+DROP FUNCTION IF EXISTS people_set_updated_at();
+
+ALTER TABLE people DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE people ALTER COLUMN created_at DROP NOT NULL;
@@ -0,0 +1,19 @@
+UPDATE people SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE people ALTER COLUMN created_at SET NOT NULL;
+
+ALTER TABLE people ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE;
+UPDATE people SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE people ALTER COLUMN updated_at SET DEFAULT NOW();
+ALTER TABLE people ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE OR REPLACE FUNCTION people_set_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS people_updated_at ON people;
+CREATE TRIGGER people_updated_at
+    BEFORE UPDATE ON people
+    FOR EACH ROW EXECUTE FUNCTION people_set_updated_at();
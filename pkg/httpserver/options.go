@@ -1,6 +1,7 @@
 package httpserver
 
 import (
+	"context"
 	"net"
 	"time"
 )
@@ -30,3 +31,11 @@ func ShutdownTimeout(timeout time.Duration) Option {
 		s.shutdownTimeout = timeout
 	}
 }
+
+// BaseContext makes ctx the parent of every request context, so that cancelling it
+// cancels the requests still running after the shutdown timeout.
+func BaseContext(ctx context.Context) Option {
+	return func(s *Server) {
+		s.server.BaseContext = func(net.Listener) context.Context { return ctx }
+	}
+}
@@ -1,12 +1,18 @@
 package kafka
 
 import (
+	"context"
 	"fmt"
 	"github.com/IBM/sarama"
+	"github.com/khasmag06/effective-mobile-test/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
 	"log"
 )
 
 type KafkaClient struct {
+	client   sarama.Client
 	consumer sarama.Consumer
 	producer sarama.SyncProducer
 }
@@ -17,29 +23,48 @@ func NewKafkaClient(brokerURLs []string) (*KafkaClient, error) {
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 
-	consumer, err := sarama.NewConsumer(brokerURLs, config)
+	client, err := sarama.NewClient(brokerURLs, config)
 	if err != nil {
+		return nil, fmt.Errorf("error creating Kafka client: %v", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("error creating Kafka consumer: %v", err)
 	}
 
-	producer, err := sarama.NewSyncProducer(brokerURLs, config)
+	producer, err := sarama.NewSyncProducerFromClient(client)
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("error creating Kafka producer: %v", err)
 	}
 
 	return &KafkaClient{
+		client:   client,
 		consumer: consumer,
 		producer: producer,
 	}, nil
 }
 
-func (k *KafkaClient) SendMessageToTopic(topic string, message []byte) error {
+// SendMessageToTopic sends the message with the trace context of ctx in its headers.
+func (k *KafkaClient) SendMessageToTopic(ctx context.Context, topic string, message []byte) (err error) {
+	ctx, span := tracer.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(topic),
+		))
+	defer func() { tracing.End(span, err) }()
+
 	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.StringEncoder(message),
 	}
+	otel.GetTextMapPropagator().Inject(ctx, producerHeaders{msg: msg})
 
-	_, _, err := k.producer.SendMessage(msg)
+	_, _, err = k.producer.SendMessage(msg)
 	if err != nil {
 		return fmt.Errorf("error sending message to topic %s: %v", topic, err)
 	}
@@ -57,7 +82,27 @@ func (k *KafkaClient) ConsumeFromTopic(topic string) (<-chan *sarama.ConsumerMes
 	return partitionConsumer.Messages(), partitionConsumer.Errors()
 }
 
+// Ping refreshes the cluster metadata from the brokers. Sarama does not take a context,
+// so the refresh is left to finish in the background when ctx is done first.
+func (k *KafkaClient) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- k.client.RefreshMetadata()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error refreshing Kafka metadata: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (k *KafkaClient) Close() {
 	k.consumer.Close()
 	k.producer.Close()
+	k.client.Close()
 }
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/khasmag06/effective-mobile-test/pkg/kafka")
+
+// StartConsumerSpan starts the span of processing a consumed message. The trace context the producer
+// put in the message headers becomes its parent, so the trace continues across the queue.
+func StartConsumerSpan(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, consumerHeaders(msg.Headers))
+
+	return tracer.Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingSourceName(msg.Topic),
+			semconv.MessagingKafkaSourcePartition(int(msg.Partition)),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		))
+}
+
+// consumerHeaders reads the trace context from the headers of a consumed message.
+type consumerHeaders []*sarama.RecordHeader
+
+func (h consumerHeaders) Get(key string) string {
+	for _, header := range h {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h consumerHeaders) Set(string, string) {}
+
+func (h consumerHeaders) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for _, header := range h {
+		if header != nil {
+			keys = append(keys, string(header.Key))
+		}
+	}
+	return keys
+}
+
+// producerHeaders writes the trace context to the headers of a message being sent.
+type producerHeaders struct {
+	msg *sarama.ProducerMessage
+}
+
+func (h producerHeaders) Get(key string) string {
+	for _, header := range h.msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h producerHeaders) Set(key, value string) {
+	h.msg.Headers = append(h.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (h producerHeaders) Keys() []string {
+	keys := make([]string, 0, len(h.msg.Headers))
+	for _, header := range h.msg.Headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
+
+var (
+	_ propagation.TextMapCarrier = consumerHeaders(nil)
+	_ propagation.TextMapCarrier = producerHeaders{}
+)
@@ -1,11 +1,28 @@
 package logger
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"strings"
 )
 
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying the key-value pairs, the context-aware methods of
+// the logger attach them to every line logged on behalf of ctx. Fields already in ctx are kept.
+func WithFields(ctx context.Context, keysAndValues ...any) context.Context {
+	parent := fieldsFromContext(ctx)
+	fields := make([]any, 0, len(parent)+len(keysAndValues))
+	fields = append(append(fields, parent...), keysAndValues...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+func fieldsFromContext(ctx context.Context) []any {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	return fields
+}
+
 type Logger struct {
 	logger *zap.SugaredLogger
 }
@@ -52,35 +69,58 @@ func New(logFilePath, level string) (*Logger, error) {
 }
 
 func (l *Logger) Debug(args ...any) {
-	l.logger.Debug(args)
+	l.logger.Debug(args...)
 }
 
 func (l *Logger) Info(args ...any) {
-	l.logger.Info(args)
+	l.logger.Info(args...)
 }
 
 func (l *Logger) Infof(format string, args ...any) {
-	l.logger.Infof(format, args)
+	l.logger.Infof(format, args...)
 }
 
 func (l *Logger) Warn(args ...any) {
-	l.logger.Warn(args)
+	l.logger.Warn(args...)
 }
 
 func (l *Logger) Error(args ...any) {
-	l.logger.Error(args)
+	l.logger.Error(args...)
 }
 
 func (l *Logger) Errorf(format string, args ...any) {
-	l.logger.Errorf(format, args)
+	l.logger.Errorf(format, args...)
 }
 
 func (l *Logger) Fatal(args ...any) {
-	l.logger.Fatal(args)
+	l.logger.Fatal(args...)
 }
 
 func (l *Logger) Fatalf(format string, args ...any) {
-	l.logger.Fatalf(format, args)
+	l.logger.Fatalf(format, args...)
+}
+
+func (l *Logger) InfoContext(ctx context.Context, args ...any) {
+	l.with(ctx).Info(args...)
+}
+
+func (l *Logger) InfofContext(ctx context.Context, format string, args ...any) {
+	l.with(ctx).Infof(format, args...)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, args ...any) {
+	l.with(ctx).Error(args...)
+}
+
+func (l *Logger) ErrorfContext(ctx context.Context, format string, args ...any) {
+	l.with(ctx).Errorf(format, args...)
+}
+
+func (l *Logger) with(ctx context.Context) *zap.SugaredLogger {
+	if fields := fieldsFromContext(ctx); len(fields) > 0 {
+		return l.logger.With(fields...)
+	}
+	return l.logger
 }
 
 func (l *Logger) Sync() error {
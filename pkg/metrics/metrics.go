@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "fio"
+
+// Metrics holds the collectors of the service in its own registry, so that the values recorded
+// by one instance of the app do not leak into another one in the tests.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	graphQLOperations  *prometheus.CounterVec
+	graphQLDuration    *prometheus.HistogramVec
+	cacheLookups       *prometheus.CounterVec
+	cacheInvalidations *prometheus.CounterVec
+	enrichmentRequests *prometheus.CounterVec
+	enrichmentDuration *prometheus.HistogramVec
+	kafkaMessages      *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent handling HTTP requests, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		graphQLOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "graphql_operations_total",
+			Help:      "GraphQL operations executed, by operation and result.",
+		}, []string{"operation", "result"}),
+		graphQLDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "graphql_operation_duration_seconds",
+			Help:      "Time spent executing GraphQL operations, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Redis cache lookups, by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		cacheInvalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_invalidations_total",
+			Help:      "Redis cache invalidations, by cache.",
+		}, []string{"cache"}),
+		enrichmentRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "enrichment_requests_total",
+			Help:      "Requests to the enrichment APIs, by API and result.",
+		}, []string{"api", "result"}),
+		enrichmentDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "enrichment_request_duration_seconds",
+			Help:      "Latency of the enrichment APIs, by API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"api"}),
+		kafkaMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "kafka_messages_total",
+			Help:      "Kafka messages, by topic and event (consumed, failed or sent).",
+		}, []string{"topic", "event"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.graphQLOperations,
+		m.graphQLDuration,
+		m.cacheLookups,
+		m.cacheInvalidations,
+		m.enrichmentRequests,
+		m.enrichmentDuration,
+		m.kafkaMessages,
+	)
+
+	return m
+}
+
+// Handler serves the collected metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Register adds a collector of another component, such as the database pool, to the registry.
+func (m *Metrics) Register(collector prometheus.Collector) error {
+	return m.registry.Register(collector)
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveGraphQLOperation(operation string, failed bool, duration time.Duration) {
+	m.graphQLOperations.WithLabelValues(operation, result(failed)).Inc()
+	m.graphQLDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (m *Metrics) CacheHit(cache string) {
+	m.cacheLookups.WithLabelValues(cache, "hit").Inc()
+}
+
+func (m *Metrics) CacheMiss(cache string) {
+	m.cacheLookups.WithLabelValues(cache, "miss").Inc()
+}
+
+func (m *Metrics) CacheInvalidated(cache string) {
+	m.cacheInvalidations.WithLabelValues(cache).Inc()
+}
+
+func (m *Metrics) ObserveEnrichment(api string, failed bool, duration time.Duration) {
+	m.enrichmentRequests.WithLabelValues(api, result(failed)).Inc()
+	m.enrichmentDuration.WithLabelValues(api).Observe(duration.Seconds())
+}
+
+func (m *Metrics) KafkaMessageConsumed(topic string) {
+	m.kafkaMessages.WithLabelValues(topic, "consumed").Inc()
+}
+
+func (m *Metrics) KafkaMessageFailed(topic string) {
+	m.kafkaMessages.WithLabelValues(topic, "failed").Inc()
+}
+
+func (m *Metrics) KafkaMessageSent(topic string) {
+	m.kafkaMessages.WithLabelValues(topic, "sent").Inc()
+}
+
+func result(failed bool) string {
+	if failed {
+		return "error"
+	}
+	return "ok"
+}
@@ -12,7 +12,13 @@ type DB struct {
 }
 
 func NewDB(ctx context.Context, cfg config.PGConfig) (*DB, error) {
-	pool, err := pgxpool.New(ctx, cfg.URL)
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = newTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
@@ -24,6 +30,10 @@ func NewDB(ctx context.Context, cfg config.PGConfig) (*DB, error) {
 	return &DB{Pool: pool}, nil
 }
 
+func (db *DB) Ping(ctx context.Context) error {
+	return db.Pool.Ping(ctx)
+}
+
 func (db *DB) Close() {
 	db.Pool.Close()
 }
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/khasmag06/effective-mobile-test/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+// tracer wraps the queries and copies of the pool in spans of the global tracer provider.
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func newTracer() *tracer {
+	return &tracer{tracer: otel.Tracer("github.com/khasmag06/effective-mobile-test/pkg/postgres")}
+}
+
+func (t *tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := sqlOperation(data.SQL)
+	ctx, _ = t.tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(operation), semconv.DBStatement(data.SQL)))
+	return ctx
+}
+
+func (t *tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endSpan(ctx, data.Err)
+}
+
+func (t *tracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "COPY "+data.TableName.Sanitize(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation("COPY"), semconv.DBSQLTable(data.TableName.Sanitize())))
+	return ctx
+}
+
+func (t *tracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endSpan(ctx, data.Err)
+}
+
+// endSpan ends the span of the query, a query that found no rows did not fail.
+func endSpan(ctx context.Context, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+	tracing.End(trace.SpanFromContext(ctx), err)
+}
+
+// sqlOperation is the first keyword of the statement, it names the span.
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
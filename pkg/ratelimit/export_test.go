@@ -0,0 +1,18 @@
+package ratelimit
+
+import "time"
+
+// NewMemoryLimiterWithClock lets the tests move the time of the limiter by hand.
+func NewMemoryLimiterWithClock(now func() time.Time) *MemoryLimiter {
+	l := NewMemoryLimiter()
+	l.now = now
+	l.lastSweep = now()
+	return l
+}
+
+// Buckets is the number of buckets the limiter keeps.
+func (l *MemoryLimiter) Buckets() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,135 @@
+package ratelimit_test
+
+import (
+	"context"
+	"github.com/khasmag06/effective-mobile-test/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+type request struct {
+	key            string
+	after          time.Duration
+	cost           int
+	expectedResult ratelimit.Result
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	// A token a second, up to three of them
+	limit := ratelimit.PerMinute(60, 3)
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "burst is spent and the next request is denied",
+			requests: []request{
+				{cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+				{cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+				{cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+				{cost: 1, expectedResult: ratelimit.Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: time.Second, ResetAfter: 3 * time.Second}},
+			},
+		},
+		{
+			name: "bucket refills with time",
+			requests: []request{
+				{cost: 3, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+				{after: 500 * time.Millisecond, cost: 1, expectedResult: ratelimit.Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 2500 * time.Millisecond}},
+				{after: 1000 * time.Millisecond, cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 2500 * time.Millisecond}},
+			},
+		},
+		{
+			name: "refill stops at the burst",
+			requests: []request{
+				{cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+				{after: time.Hour, cost: 2, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+			},
+		},
+		{
+			name: "denied request takes no tokens",
+			requests: []request{
+				{cost: 2, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second}},
+				{cost: 2, expectedResult: ratelimit.Result{Allowed: false, Limit: 3, Remaining: 1, RetryAfter: time.Second, ResetAfter: 2 * time.Second}},
+				{cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+			},
+		},
+		{
+			name: "cost above the burst is capped",
+			requests: []request{
+				{cost: 10, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+				{after: 2 * time.Second, cost: 10, expectedResult: ratelimit.Result{Allowed: false, Limit: 3, Remaining: 2, RetryAfter: time.Second, ResetAfter: time.Second}},
+			},
+		},
+		{
+			name: "keys have their own buckets",
+			requests: []request{
+				{key: "ip:10.0.0.1", cost: 3, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second}},
+				{key: "ip:10.0.0.2", cost: 1, expectedResult: ratelimit.Result{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &clock{now: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)}
+			limiter := ratelimit.NewMemoryLimiterWithClock(c.Now)
+
+			for i, req := range test.requests {
+				c.now = c.now.Add(req.after)
+				key := req.key
+				if key == "" {
+					key = "sub:user-1"
+				}
+
+				result, err := limiter.Allow(context.Background(), key, limit, req.cost)
+
+				assert.NoError(t, err, "Test case %s failed: Error not as expected", test.name)
+				assert.Equal(t, req.expectedResult, result, "Test case %s failed: Result of request %d not as expected", test.name, i)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter_Sweep(t *testing.T) {
+	c := &clock{now: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := ratelimit.NewMemoryLimiterWithClock(c.Now)
+	ctx := context.Background()
+
+	_, _ = limiter.Allow(ctx, "sub:idle", ratelimit.PerMinute(60, 3), 1)
+	_, _ = limiter.Allow(ctx, "sub:busy", ratelimit.PerMinute(1, 3), 3)
+	assert.Equal(t, 2, limiter.Buckets())
+
+	// A minute later the idle bucket is full again and dropped, the busy one has a token of three
+	c.now = c.now.Add(time.Minute)
+	_, _ = limiter.Allow(ctx, "sub:other", ratelimit.PerMinute(60, 3), 1)
+	assert.Equal(t, 2, limiter.Buckets())
+}
+
+func TestLimit_Enabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    ratelimit.Limit
+		expected bool
+	}{
+		{name: "per minute", limit: ratelimit.PerMinute(600, 60), expected: true},
+		{name: "zero rate", limit: ratelimit.PerMinute(0, 60), expected: false},
+		{name: "zero burst", limit: ratelimit.PerMinute(600, 0), expected: false},
+		{name: "zero limit", limit: ratelimit.Limit{}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.limit.Enabled(), "Test case %s failed: Result not as expected", test.name)
+		})
+	}
+}
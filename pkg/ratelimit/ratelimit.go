@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Limiter takes tokens from the bucket of a key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit, cost int) (Result, error)
+}
+
+// New returns the limiter of the backend: the memory one for a single instance, or the Redis one
+// whose buckets are shared by all the instances using rdb.
+func New(backend string, rdb *redis.Client) (Limiter, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemoryLimiter(), nil
+	case BackendRedis:
+		return NewRedisLimiter(rdb), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q, expected %s or %s", backend, BackendMemory, BackendRedis)
+	}
+}
+
+// Limit is a token bucket: it holds up to Burst tokens and refills at Rate tokens per second.
+// A zero rate disables the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests a minute on average with bursts of up to burst requests.
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking tokens from a bucket.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a denied request has to wait for enough tokens.
+	RetryAfter time.Duration
+	// ResetAfter is how long the bucket takes to fill up again.
+	ResetAfter time.Duration
+}
+
+// newResult describes a bucket left with the given tokens after a request of the given cost.
+// Costs above the burst are capped by the limiters, so that every request can pass eventually.
+func newResult(limit Limit, tokens float64, cost int, allowed bool) Result {
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((float64(cost) - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
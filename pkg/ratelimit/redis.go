@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeTokens refills the bucket stored in a hash by the time passed since its last update and takes
+// the cost from it when there are enough tokens. The Redis clock is used, so that all the replicas
+// agree on the time. A bucket expires once it would be full again.
+var takeTokens = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) / 1000 * rate)
+
+local allowed = 0
+if tokens >= cost then
+  tokens = tokens - cost
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter keeps the buckets in Redis, so that the replicas of the service share them.
+type RedisLimiter struct {
+	redis *redis.Client
+}
+
+func NewRedisLimiter(rdb *redis.Client) *RedisLimiter {
+	return &RedisLimiter{redis: rdb}
+}
+
+// Allow takes cost tokens from the bucket of the key if it has enough of them.
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit, cost int) (Result, error) {
+	cost = min(cost, limit.Burst)
+
+	reply, err := takeTokens.Run(ctx, l.redis, []string{"rl:" + key}, limit.Rate, limit.Burst, cost).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rateLimiter - Allow - takeTokens.Run: %w", err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("rateLimiter - Allow - unexpected reply %v", reply)
+	}
+	allowed, _ := reply[0].(int64)
+	tokensReply, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(tokensReply, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("rateLimiter - Allow - strconv.ParseFloat: %w", err)
+	}
+
+	return newResult(limit, tokens, cost, allowed == 1), nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/khasmag06/effective-mobile-test/config"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -13,6 +14,10 @@ func ConnectRedis(ctx context.Context, redisConfig config.RedisConfig) (*redis.C
 		Addr:     redisAddress,
 		Password: redisConfig.Password,
 		DB:       redisConfig.DB,
+		// Commands give up after the configured timeout or when their context is done, whichever comes first.
+		ReadTimeout:           redisConfig.Timeout,
+		WriteTimeout:          redisConfig.Timeout,
+		ContextTimeoutEnabled: true,
 	})
 
 	err := rdb.Ping(ctx).Err()
@@ -20,5 +25,9 @@ func ConnectRedis(ctx context.Context, redisConfig config.RedisConfig) (*redis.C
 		return nil, fmt.Errorf(`connect redis ping: %w`, err)
 	}
 
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		return nil, fmt.Errorf(`instrument redis tracing: %w`, err)
+	}
+
 	return rdb, nil
 }
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"reflect"
@@ -12,6 +13,26 @@ type CustomValidator struct {
 	v *validator.Validate
 }
 
+// ValidationError lists every field of a struct that failed validation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// FieldError describes a single field that failed validation. The field is the path
+// of JSON names inside the validated struct.
+type FieldError struct {
+	Field   string `json:"field" example:"name"`
+	Message string `json:"message" example:"field name is required"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
 func NewCustomValidator() *CustomValidator {
 	v := validator.New()
 	cv := &CustomValidator{v: v}
@@ -35,35 +56,61 @@ func NewCustomValidator() *CustomValidator {
 func (cv *CustomValidator) Validate(i interface{}) error {
 	err := cv.v.Struct(i)
 	if err != nil {
-		fieldErr := err.(validator.ValidationErrors)[0]
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return err
+		}
 
-		return cv.newValidationError(fieldErr)
+		return cv.newValidationError(fieldErrs)
 	}
 	return nil
 }
 
-func (cv *CustomValidator) newValidationError(fe validator.FieldError) error {
+func (cv *CustomValidator) newValidationError(fieldErrs validator.ValidationErrors) error {
+	validationErr := &ValidationError{Fields: make([]FieldError, 0, len(fieldErrs))}
+	for _, fe := range fieldErrs {
+		validationErr.Fields = append(validationErr.Fields, FieldError{
+			Field:   fieldPath(fe),
+			Message: validationMessage(fe),
+		})
+	}
+	return validationErr
+}
+
+// fieldPath drops the struct name from the namespace of the field, leaving its JSON path.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func validationMessage(fe validator.FieldError) string {
 	switch fe.Tag() {
 	case "required":
-		return fmt.Errorf("field %s is required", fe.Field())
+		return fmt.Sprintf("field %s is required", fe.Field())
 	case "email":
-		return fmt.Errorf("field %s must be a valid email address", fe.Field())
+		return fmt.Sprintf("field %s must be a valid email address", fe.Field())
 	case "startsWithUpperCase":
-		return fmt.Errorf("field %s must start with an upper case letter", fe.Field())
+		return fmt.Sprintf("field %s must start with an upper case letter", fe.Field())
 	case "min":
-		return fmt.Errorf("field %s must be at least %s characters", fe.Field(), fe.Param())
+		return fmt.Sprintf("field %s must be at least %s characters", fe.Field(), fe.Param())
 	case "max":
-		return fmt.Errorf("field %s must be at most %s characters", fe.Field(), fe.Param())
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("field %s must have at most %s items", fe.Field(), fe.Param())
+		}
+		return fmt.Sprintf("field %s must be at most %s characters", fe.Field(), fe.Param())
 	case "gte":
-		return fmt.Errorf("field %s must be greater than or equal to %s", fe.Field(), fe.Param())
+		return fmt.Sprintf("field %s must be greater than or equal to %s", fe.Field(), fe.Param())
 	case "lte":
-		return fmt.Errorf("field %s must be less than or equal to %s", fe.Field(), fe.Param())
+		return fmt.Sprintf("field %s must be less than or equal to %s", fe.Field(), fe.Param())
 	case "alpha":
-		return fmt.Errorf("field %s must contain only alpha characters", fe.Field())
+		return fmt.Sprintf("field %s must contain only alpha characters", fe.Field())
 	case "oneof":
-		return fmt.Errorf("field %s must be one of (%s)", fe.Field(), fe.Param())
+		return fmt.Sprintf("field %s must be one of (%s)", fe.Field(), fe.Param())
 	default:
-		return fmt.Errorf("field %s is invalid", fe.Field())
+		return fmt.Sprintf("field %s is invalid", fe.Field())
 	}
 }
 